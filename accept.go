@@ -0,0 +1,39 @@
+package blaze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// qualityValue is an entry of a header with quality values like Accept or Accept-Encoding
+type qualityValue struct {
+	value string
+	q     float64
+}
+
+// parseQualityList parses a header value like "gzip;q=0.8, zstd" and returns the entries
+// ordered by descending quality. Entries of equal quality keep their order
+func parseQualityList(header string) []qualityValue {
+	var values []qualityValue
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		v := strings.ToLower(strings.TrimSpace(params[0]))
+		if v == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			k, val, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if !ok || strings.TrimSpace(strings.ToLower(k)) != "q" {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+				q = f
+			}
+		}
+		values = append(values, qualityValue{value: v, q: q})
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].q > values[j].q })
+	return values
+}
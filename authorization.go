@@ -0,0 +1,17 @@
+package blaze
+
+// MethodAuthorization lists the requirements of a method declared with the scopes, roles and public
+// fields of the blaze.options.v1.method option
+type MethodAuthorization struct {
+	// Scopes are all required from the principal of a call
+	Scopes []string
+	// Roles of which the principal of a call needs at least one
+	Roles []string
+	// Public methods may be called without authentication
+	Public bool
+}
+
+// AuthorizationTable maps the paths of methods, e.g. /health/v1/Check, to their requirements.
+// Generated services provide the table of their annotated methods as <Service>Authorization,
+// the tables of services sharing a router can be merged into one
+type AuthorizationTable map[string]MethodAuthorization
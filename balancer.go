@@ -0,0 +1,255 @@
+package blaze
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancerPolicy picks the endpoint of a call
+type BalancerPolicy int
+
+const (
+	// RoundRobin picks the endpoints in turn
+	RoundRobin BalancerPolicy = iota
+	// LeastOutstanding picks the endpoint with the fewest calls in flight
+	LeastOutstanding
+	// PowerOfTwoChoices picks the endpoint with fewer calls in flight out of two random endpoints
+	PowerOfTwoChoices
+)
+
+// BalancerOption is a functional option for extending a balancing client
+type BalancerOption func(*balancingClient)
+
+// WithBalancerPolicy sets the policy picking the endpoint of a call. The default is RoundRobin
+func WithBalancerPolicy(policy BalancerPolicy) BalancerOption {
+	return func(c *balancingClient) {
+		c.policy = policy
+	}
+}
+
+// WithEjection sets the number of consecutive failures after which an endpoint is ejected and for how long.
+// A threshold of 0 disables ejection
+func WithEjection(failures int, duration time.Duration) BalancerOption {
+	return func(c *balancingClient) {
+		c.ejectionThreshold = failures
+		c.ejectionDuration = duration
+	}
+}
+
+type balancingClient struct {
+	client            HTTPClient
+	resolver          Resolver
+	policy            BalancerPolicy
+	ejectionThreshold int
+	ejectionDuration  time.Duration
+	next              atomic.Uint64
+	// httpClient and transport are set if client is an *http.Client with an *http.Transport,
+	// their copies dial the address of an endpoint
+	httpClient *http.Client
+	transport  *http.Transport
+
+	mu      sync.Mutex
+	stats   map[string]*endpointStats
+	clients map[string]*http.Client
+}
+
+type endpointStats struct {
+	outstanding  atomic.Int64
+	failures     int
+	ejectedUntil time.Time
+}
+
+// NewBalancingClient wraps client to spread calls across the endpoints of resolver.
+// Requests keep their URL and Host, so generated clients are created with the address of the service
+// e.g. https://service, which is used for virtual hosting and the TLS server name.
+// The connections of an *http.Client with an *http.Transport (or the default transport) dial the address
+// of the picked endpoint, other clients find it with EndpointFromContext.
+// Endpoints failing with unavailable errors consecutively are ejected for a while
+func NewBalancingClient(client HTTPClient, resolver Resolver, opts ...BalancerOption) HTTPClient {
+	c := &balancingClient{
+		client:            client,
+		resolver:          resolver,
+		ejectionThreshold: 3,
+		ejectionDuration:  30 * time.Second,
+		stats:             map[string]*endpointStats{},
+		clients:           map[string]*http.Client{},
+	}
+	if hc, ok := client.(*http.Client); ok {
+		rt := hc.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		if t, ok := rt.(*http.Transport); ok {
+			c.httpClient, c.transport = hc, t
+		}
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+type endpointKey struct{}
+
+// EndpointFromContext returns the address of the endpoint a balancing client picked for the request of the context
+func EndpointFromContext(ctx context.Context) (string, bool) {
+	address, ok := ctx.Value(endpointKey{}).(string)
+	return address, ok
+}
+
+func (c *balancingClient) Do(req *http.Request) (*http.Response, error) {
+	address, stats, ok := c.pick(endpointExclusionFromContext(req.Context()))
+	if !ok {
+		return nil, ErrorUnavailable("no endpoints available for " + req.URL.Host)
+	}
+	r := req.WithContext(context.WithValue(req.Context(), endpointKey{}, address))
+	stats.outstanding.Add(1)
+	resp, err := c.endpointClient(address).Do(r)
+	stats.outstanding.Add(-1)
+	var blerr Error
+	blerr, resp = classifyAttempt(resp, err)
+	c.record(stats, blerr)
+	return resp, err
+}
+
+// endpointClient returns the client sending requests to the endpoint. Copies of an *http.Client
+// get a transport per endpoint, as connections are pooled by the host of the URL
+func (c *balancingClient) endpointClient(address string) HTTPClient {
+	if c.transport == nil {
+		return c.client
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hc, ok := c.clients[address]; ok {
+		return hc
+	}
+	t := c.transport.Clone()
+	// the endpoint is dialed directly instead of through a proxy
+	t.Proxy = nil
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dial(ctx, network, address)
+	}
+	if dialTLS := t.DialTLSContext; dialTLS != nil {
+		t.DialTLSContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialTLS(ctx, network, address)
+		}
+	}
+	hc := *c.httpClient
+	hc.Transport = t
+	c.clients[address] = &hc
+	return &hc
+}
+
+// pick chooses an endpoint which is not ejected. If all endpoints are ejected all of them are considered.
+// Endpoints already used by another copy of a hedged call are avoided
+func (c *balancingClient) pick(exclusion *endpointExclusion) (string, *endpointStats, bool) {
+	endpoints := c.resolver.Endpoints()
+	if len(endpoints) == 0 {
+		return "", nil, false
+	}
+	now := time.Now()
+	c.mu.Lock()
+	if len(c.stats) > 2*len(endpoints) {
+		c.pruneStats(endpoints)
+	}
+	candidates := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		if s, ok := c.stats[e.Address]; !ok || !now.Before(s.ejectedUntil) {
+			candidates = append(candidates, e.Address)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, e := range endpoints {
+			candidates = append(candidates, e.Address)
+		}
+	}
+	if exclusion != nil {
+		unused := make([]string, 0, len(candidates))
+		for _, a := range candidates {
+			if !exclusion.contains(a) {
+				unused = append(unused, a)
+			}
+		}
+		if len(unused) > 0 {
+			candidates = unused
+		}
+	}
+	stats := make([]*endpointStats, len(candidates))
+	for i, a := range candidates {
+		s, ok := c.stats[a]
+		if !ok {
+			s = &endpointStats{}
+			c.stats[a] = s
+		}
+		stats[i] = s
+	}
+	c.mu.Unlock()
+
+	var i int
+	switch c.policy {
+	case LeastOutstanding:
+		offset := c.next.Add(1)
+		for j := range stats {
+			k := int((offset + uint64(j)) % uint64(len(stats)))
+			if stats[k].outstanding.Load() < stats[i].outstanding.Load() || j == 0 {
+				i = k
+			}
+		}
+	case PowerOfTwoChoices:
+		i = rand.Intn(len(stats))
+		if k := rand.Intn(len(stats)); stats[k].outstanding.Load() < stats[i].outstanding.Load() {
+			i = k
+		}
+	default:
+		i = int((c.next.Add(1) - 1) % uint64(len(stats)))
+	}
+	if exclusion != nil {
+		exclusion.add(candidates[i])
+	}
+	return candidates[i], stats[i], true
+}
+
+// pruneStats forgets endpoints which are no longer resolved. It must be called with the lock held
+func (c *balancingClient) pruneStats(endpoints []Endpoint) {
+	known := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		known[e.Address] = true
+	}
+	for a := range c.stats {
+		if !known[a] {
+			delete(c.stats, a)
+		}
+	}
+	for a, hc := range c.clients {
+		if !known[a] {
+			hc.CloseIdleConnections()
+			delete(c.clients, a)
+		}
+	}
+}
+
+func (c *balancingClient) record(stats *endpointStats, blerr Error) {
+	if c.ejectionThreshold <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if blerr == nil || blerr.Type() != ErrorUnavailable("").Type() {
+		stats.failures = 0
+		return
+	}
+	stats.failures++
+	if stats.failures >= c.ejectionThreshold {
+		stats.failures = 0
+		stats.ejectedUntil = time.Now().Add(c.ejectionDuration)
+	}
+}
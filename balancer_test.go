@@ -0,0 +1,208 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+// hostClient answers requests by their endpoint and counts the requests per endpoint
+type hostClient struct {
+	mu      sync.Mutex
+	answers map[string]attempt
+	hosts   []string
+}
+
+func (c *hostClient) Do(req *http.Request) (*http.Response, error) {
+	host, _ := blaze.EndpointFromContext(req.Context())
+	c.mu.Lock()
+	c.hosts = append(c.hosts, host)
+	answer, ok := c.answers[host]
+	c.mu.Unlock()
+	if !ok {
+		answer = respond(200, host)
+	}
+	return answer(req)
+}
+
+func (c *hostClient) picked() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.hosts...)
+}
+
+func (c *hostClient) count(host string) int {
+	n := 0
+	for _, h := range c.picked() {
+		if h == host {
+			n++
+		}
+	}
+	return n
+}
+
+var _ = Describe("BalancingClient", func() {
+	var inner *hostClient
+	resolver := blaze.NewStaticResolver("a:1", "b:2", "c:3")
+	BeforeEach(func() {
+		inner = &hostClient{answers: map[string]attempt{}}
+	})
+	call := func(client blaze.HTTPClient) (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(context.Background(), "POST", "http://service/svc/Method", nil)
+		return client.Do(req)
+	}
+
+	It("keeps the host of the request and passes the endpoint in the context", func() {
+		resp, err := call(blaze.NewBalancingClient(inner, blaze.NewStaticResolver("a:1")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Request.URL.String()).To(Equal("http://service/svc/Method"))
+		Expect(resp.Request.Host).To(Equal("service"))
+		Expect(readBody(resp)).To(Equal("a:1"))
+	})
+
+	Context("with an *http.Client", func() {
+		// serve starts servers answering with their name and the Host of the request
+		serve := func(newServer func(http.Handler) *httptest.Server, names ...string) []string {
+			var addresses []string
+			for _, name := range names {
+				name := name
+				srv := newServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+					_, _ = resp.Write([]byte(name + " " + req.Host))
+				}))
+				DeferCleanup(srv.Close)
+				addresses = append(addresses, srv.Listener.Addr().String())
+			}
+			return addresses
+		}
+
+		It("dials the endpoints", func() {
+			client := blaze.NewBalancingClient(&http.Client{}, blaze.NewStaticResolver(serve(httptest.NewServer, "a", "b")...))
+			var bodies []string
+			for i := 0; i < 4; i++ {
+				resp, err := call(client)
+				Expect(err).ToNot(HaveOccurred())
+				bodies = append(bodies, readBody(resp))
+			}
+			Expect(bodies).To(Equal([]string{"a service", "b service", "a service", "b service"}))
+		})
+
+		It("verifies the TLS certificate of the host", func() {
+			var tlsClient *http.Client
+			addresses := serve(func(h http.Handler) *httptest.Server {
+				srv := httptest.NewTLSServer(h)
+				tlsClient = srv.Client()
+				return srv
+			}, "a")
+			client := blaze.NewBalancingClient(tlsClient, blaze.NewStaticResolver(addresses...))
+			// the certificate of the test server is valid for example.com
+			req, _ := http.NewRequestWithContext(context.Background(), "POST", "https://example.com/svc/Method", nil)
+			resp, err := client.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(readBody(resp)).To(Equal("a example.com"))
+
+			req, _ = http.NewRequestWithContext(context.Background(), "POST", "https://service/svc/Method", nil)
+			_, err = client.Do(req)
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+	})
+
+	It("fails without endpoints", func() {
+		_, err := call(blaze.NewBalancingClient(inner, blaze.NewStaticResolver()))
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorUnavailable("").Type()))
+	})
+
+	It("picks the endpoints in turn with RoundRobin", func() {
+		client := blaze.NewBalancingClient(inner, resolver, blaze.WithBalancerPolicy(blaze.RoundRobin))
+		for i := 0; i < 6; i++ {
+			_, err := call(client)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(inner.picked()).To(Equal([]string{"a:1", "b:2", "c:3", "a:1", "b:2", "c:3"}))
+	})
+
+	// busy keeps a call to the host in flight until the returned function is called
+	busy := func(client blaze.HTTPClient, host string) func() {
+		release := make(chan struct{})
+		var first sync.Once
+		inner.answers[host] = func(req *http.Request) (*http.Response, error) {
+			blocked := false
+			first.Do(func() { blocked = true })
+			if blocked {
+				<-release
+			}
+			return respond(200, "")(req)
+		}
+		for inner.count(host) == 0 {
+			go func() { _, _ = call(client) }()
+			time.Sleep(time.Millisecond)
+		}
+		return func() { close(release) }
+	}
+
+	It("avoids busy endpoints with LeastOutstanding", func() {
+		client := blaze.NewBalancingClient(inner, resolver, blaze.WithBalancerPolicy(blaze.LeastOutstanding))
+		defer busy(client, "a:1")()
+		before := len(inner.picked())
+		busyCalls := inner.count("a:1")
+		for i := 0; i < 20; i++ {
+			_, err := call(client)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(inner.count("a:1")).To(Equal(busyCalls))
+		Expect(inner.picked()[before:]).To(ContainElements("b:2", "c:3"))
+	})
+
+	It("prefers the less busy of two endpoints with PowerOfTwoChoices", func() {
+		client := blaze.NewBalancingClient(inner, blaze.NewStaticResolver("a:1", "b:2"), blaze.WithBalancerPolicy(blaze.PowerOfTwoChoices))
+		defer busy(client, "a:1")()
+		before := inner.count("a:1")
+		for i := 0; i < 200; i++ {
+			_, err := call(client)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		// the busy endpoint is only picked if both choices fall on it
+		Expect(inner.count("a:1") - before).To(BeNumerically("<", 100))
+	})
+
+	It("ejects endpoints failing consecutively", func() {
+		inner.answers["a:1"] = respond(503, "")
+		client := blaze.NewBalancingClient(inner, blaze.NewStaticResolver("a:1", "b:2"), blaze.WithEjection(2, time.Hour))
+		for i := 0; i < 10; i++ {
+			_, _ = call(client)
+		}
+		Expect(inner.count("a:1")).To(Equal(2))
+		Expect(inner.count("b:2")).To(Equal(8))
+	})
+
+	It("considers all endpoints once all are ejected", func() {
+		inner.answers["a:1"] = respond(503, "")
+		client := blaze.NewBalancingClient(inner, blaze.NewStaticResolver("a:1"), blaze.WithEjection(1, time.Hour))
+		for i := 0; i < 3; i++ {
+			_, err := call(client)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(inner.count("a:1")).To(Equal(3))
+	})
+
+	It("readmits ejected endpoints after the ejection", func() {
+		inner.answers["a:1"] = respond(503, "")
+		client := blaze.NewBalancingClient(inner, blaze.NewStaticResolver("a:1", "b:2"), blaze.WithEjection(1, 30*time.Millisecond))
+		_, _ = call(client)
+		for i := 0; i < 4; i++ {
+			_, _ = call(client)
+		}
+		Expect(inner.count("a:1")).To(Equal(1))
+		time.Sleep(40 * time.Millisecond)
+		_, _ = call(client)
+		_, _ = call(client)
+		Expect(inner.count("a:1")).To(Equal(2))
+	})
+})
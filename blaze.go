@@ -29,8 +29,12 @@
 package blaze
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"code.cestus.io/blaze/pkg/blazemetrics"
 	"code.cestus.io/blaze/pkg/blazetrace"
 	"github.com/go-chi/chi/v5"
 )
@@ -52,8 +56,26 @@ type ServiceOptions struct {
 	JSONEnumsAsInts bool
 	// Whether to render fields with zero values.
 	JSONEmitDefaults bool
+	// Whether to reject json requests with unknown fields instead of ignoring them.
+	JSONRejectUnknownFields bool
+	// Whether to render lowerCamelCase json names instead of the proto field names.
+	JSONCamelCase bool
+	// Indent pretty prints json responses with the given indent. Empty renders compact json.
+	JSONIndent string
+	// Whether to accept and render messages with missing required fields.
+	JSONAllowPartial bool
 	// Trace implementation for distributed tracing
 	Trace blazetrace.ServiceTracer
+	// Metrics implementation for rpc metrics
+	Metrics blazemetrics.ServiceMetrics
+	// MaxTimeout caps the timeout requested by clients. Zero means no limit
+	MaxTimeout time.Duration
+	// CompressionThreshold is the size in bytes from which responses are compressed.
+	// Zero uses DefaultCompressionThreshold, a negative value disables compression
+	CompressionThreshold int
+	// MaxRequestBytes limits the size of request bodies of methods without a limit of their own.
+	// Zero uses DefaultMaxRequestBytes, a negative value disables the limit
+	MaxRequestBytes int64
 }
 
 // WithMux allows to set the chi mux to use by a service
@@ -77,6 +99,34 @@ func WithJSONEmitDefaults(v bool) ServiceOption {
 	}
 }
 
+// WithJSONRejectUnknownFields makes json requests with unknown fields fail with a malformed error
+func WithJSONRejectUnknownFields(v bool) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.JSONRejectUnknownFields = v
+	}
+}
+
+// WithJSONCamelCase makes JSON structs render lowerCamelCase names instead of the proto field names
+func WithJSONCamelCase(v bool) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.JSONCamelCase = v
+	}
+}
+
+// WithJSONIndent makes JSON structs render with the given indent
+func WithJSONIndent(indent string) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.JSONIndent = indent
+	}
+}
+
+// WithJSONAllowPartial makes JSON structs accept and render messages with missing required fields
+func WithJSONAllowPartial(v bool) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.JSONAllowPartial = v
+	}
+}
+
 // WithServiceTracer replaces the default tracer
 func WithServiceTracer(trace blazetrace.ServiceTracer) ServiceOption {
 	return func(o *ServiceOptions) {
@@ -84,6 +134,34 @@ func WithServiceTracer(trace blazetrace.ServiceTracer) ServiceOption {
 	}
 }
 
+// WithServiceMetrics replaces the default metrics
+func WithServiceMetrics(metrics blazemetrics.ServiceMetrics) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.Metrics = metrics
+	}
+}
+
+// WithMaxTimeout caps the time a request is served. It applies to requests without a Blaze-Timeout header as well
+func WithMaxTimeout(timeout time.Duration) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.MaxTimeout = timeout
+	}
+}
+
+// WithCompressionThreshold sets the size in bytes from which responses are compressed. A negative value disables compression
+func WithCompressionThreshold(threshold int) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.CompressionThreshold = threshold
+	}
+}
+
+// WithMaxRequestBytes limits the size of request bodies. A negative value disables the limit
+func WithMaxRequestBytes(limit int64) ServiceOption {
+	return func(o *ServiceOptions) {
+		o.MaxRequestBytes = limit
+	}
+}
+
 // ClientOption is a functional option for extending a Blaze client.
 type ClientOption func(*ClientOptions)
 
@@ -91,6 +169,90 @@ type ClientOption func(*ClientOptions)
 type ClientOptions struct {
 	// Trace implementation for distributed tracing
 	Trace blazetrace.ClientTracer
+	// Metrics implementation for rpc metrics
+	Metrics blazemetrics.ClientMetrics
+	// Compression is the content encoding of requests (gzip or zstd). Empty disables compression
+	Compression string
+	// CompressionThreshold is the size in bytes from which requests are compressed. Zero uses DefaultCompressionThreshold
+	CompressionThreshold int
+	// Codec encodes the messages of the client. The default is ProtobufCodec
+	Codec Codec
+	// Whether the json codec rejects responses with unknown fields instead of ignoring them.
+	JSONRejectUnknownFields bool
+	// Whether the json codec renders lowerCamelCase names instead of the proto field names.
+	JSONCamelCase bool
+	// Indent pretty prints json requests with the given indent. Empty renders compact json.
+	JSONIndent string
+	// Whether the json codec accepts and renders messages with missing required fields.
+	JSONAllowPartial bool
+	// Credentials authenticate the calls of the client
+	Credentials Credentials
+}
+
+// WithClientMetrics replaces the default metrics
+func WithClientMetrics(metrics blazemetrics.ClientMetrics) ClientOption {
+	return func(o *ClientOptions) {
+		o.Metrics = metrics
+	}
+}
+
+// WithCompression makes the client compress requests with the content encoding (gzip or zstd)
+// and accept compressed responses. An empty encoding disables compression.
+// Generated clients with an unsupported encoding fail every call, see ClientOptions.Validate
+func WithCompression(encoding string) ClientOption {
+	return func(o *ClientOptions) {
+		o.Compression = encoding
+	}
+}
+
+// Validate reports invalid client options like an unsupported compression
+func (o *ClientOptions) Validate() error {
+	if o.Compression != "" && !SupportedCompression(o.Compression) {
+		return errors.New("unsupported compression " + strconv.Quote(o.Compression))
+	}
+	return nil
+}
+
+// WithClientCompressionThreshold sets the size in bytes from which requests are compressed
+func WithClientCompressionThreshold(threshold int) ClientOption {
+	return func(o *ClientOptions) {
+		o.CompressionThreshold = threshold
+	}
+}
+
+// WithCodec sets the codec encoding the messages of the client
+func WithCodec(codec Codec) ClientOption {
+	return func(o *ClientOptions) {
+		o.Codec = codec
+	}
+}
+
+// WithClientJSONRejectUnknownFields makes the json codec reject responses with unknown fields
+func WithClientJSONRejectUnknownFields(v bool) ClientOption {
+	return func(o *ClientOptions) {
+		o.JSONRejectUnknownFields = v
+	}
+}
+
+// WithClientJSONCamelCase makes the json codec render lowerCamelCase names instead of the proto field names
+func WithClientJSONCamelCase(v bool) ClientOption {
+	return func(o *ClientOptions) {
+		o.JSONCamelCase = v
+	}
+}
+
+// WithClientJSONIndent makes the json codec render requests with the given indent
+func WithClientJSONIndent(indent string) ClientOption {
+	return func(o *ClientOptions) {
+		o.JSONIndent = indent
+	}
+}
+
+// WithClientJSONAllowPartial makes the json codec accept and render messages with missing required fields
+func WithClientJSONAllowPartial(v bool) ClientOption {
+	return func(o *ClientOptions) {
+		o.JSONAllowPartial = v
+	}
 }
 
 // HTTPClient is the interface used by generated clients to send HTTP requests.
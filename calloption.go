@@ -0,0 +1,115 @@
+package blaze
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallOption is a functional option for a single call of a generated client method, e.g.
+//
+//	out, err := client.(pkg.ServiceClient).MethodWithOptions(ctx, in, blaze.WithTimeout(time.Second))
+type CallOption func(*CallOptions)
+
+// CallOptions encapsulate the configurable parameters of a single call.
+type CallOptions struct {
+	// Header is added to the headers of the request
+	Header http.Header
+	// Timeout bounds the duration of the call. Zero keeps the deadline of the context
+	Timeout time.Duration
+	// RetryPolicy replaces the policy of a retrying client for the call
+	RetryPolicy *RetryPolicy
+	// Codec replaces the codec of the client for the call
+	Codec Codec
+	// Headers receives the headers and trailers of the response
+	Headers *http.Header
+}
+
+// NewCallOptions applies the call options
+func NewCallOptions(opts ...CallOption) *CallOptions {
+	o := &CallOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithHeader adds a header to the request of the call
+func WithHeader(key, value string) CallOption {
+	return func(o *CallOptions) {
+		if o.Header == nil {
+			o.Header = http.Header{}
+		}
+		o.Header.Add(key, value)
+	}
+}
+
+// WithTimeout bounds the duration of the call, including retries and hedged requests
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *CallOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithRetryPolicy replaces the policy of a client created with NewRetryingClient for the call.
+// The call is retried by the policy even if its method is not idempotent
+func WithRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *CallOptions) {
+		o.RetryPolicy = &policy
+	}
+}
+
+// WithCodecOverride encodes the call with the codec instead of the codec of the client
+func WithCodecOverride(codec Codec) CallOption {
+	return func(o *CallOptions) {
+		o.Codec = codec
+	}
+}
+
+// CaptureHeaders stores the headers and trailers of the response, successful or not, in h
+func CaptureHeaders(h *http.Header) CallOption {
+	return func(o *CallOptions) {
+		o.Headers = h
+	}
+}
+
+// Context applies the timeout and retry policy of the call to the context
+func (o *CallOptions) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.RetryPolicy != nil {
+		ctx = context.WithValue(ctx, retryPolicyKey{}, *o.RetryPolicy)
+	}
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
+	}
+	return ctx, func() {}
+}
+
+// CodecOr returns the codec of the call or c if the call does not override it
+func (o *CallOptions) CodecOr(c Codec) Codec {
+	if o.Codec != nil {
+		return o.Codec
+	}
+	return c
+}
+
+// PrepareRequest adds the headers of the call to the request
+func (o *CallOptions) PrepareRequest(req *http.Request) {
+	for k, v := range o.Header {
+		for _, value := range v {
+			req.Header.Add(k, value)
+		}
+	}
+}
+
+// CaptureResponse hands the headers of the response to the options capturing them.
+// Trailers are captured once the body was read
+func (o *CallOptions) CaptureResponse(resp *http.Response) {
+	if o == nil || o.Headers == nil {
+		return
+	}
+	h := resp.Header.Clone()
+	for k, v := range resp.Trailer {
+		h[k] = v
+	}
+	*o.Headers = h
+}
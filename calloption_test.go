@@ -0,0 +1,104 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+type servingHealth struct{}
+
+func (servingHealth) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	blaze.SetHeader(ctx, "X-Echo", blaze.RequestHeaders(ctx).Get("X-Test"))
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+var _ = Describe("CallOptions", func() {
+	It("are empty without options", func() {
+		o := blaze.NewCallOptions()
+		Expect(o.Header).To(BeNil())
+		Expect(o.CodecOr(blaze.ProtobufCodec{})).To(Equal(blaze.ProtobufCodec{}))
+	})
+
+	It("apply later options last", func() {
+		o := blaze.NewCallOptions(blaze.WithHeader("X-First", "1"), blaze.WithTimeout(time.Minute), blaze.WithHeader("X-Second", "2"), blaze.WithTimeout(time.Second))
+		Expect(o.Timeout).To(Equal(time.Second))
+		req, _ := http.NewRequest("POST", "http://localhost/svc/Method", nil)
+		o.PrepareRequest(req)
+		Expect(req.Header.Get("X-First")).To(Equal("1"))
+		Expect(req.Header.Get("X-Second")).To(Equal("2"))
+	})
+
+	It("bound the context with the timeout", func() {
+		ctx, cancel := blaze.NewCallOptions(blaze.WithTimeout(time.Second)).Context(context.Background())
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Second), 100*time.Millisecond))
+	})
+
+	It("capture headers and trailers of the response", func() {
+		var h http.Header
+		o := blaze.NewCallOptions(blaze.CaptureHeaders(&h))
+		o.CaptureResponse(&http.Response{
+			Header:  http.Header{"Etag": {`"v1"`}},
+			Trailer: http.Header{"X-Checksum": {"abc"}},
+		})
+		Expect(h.Get("ETag")).To(Equal(`"v1"`))
+		Expect(h.Get("X-Checksum")).To(Equal("abc"))
+	})
+
+	Context("of generated clients", func() {
+		var (
+			srv    *httptest.Server
+			client health_v1.HealthClient
+		)
+
+		BeforeEach(func() {
+			svc := health_v1.NewHealthService(servingHealth{}, logr.Discard())
+			mux := chi.NewMux()
+			mux.Mount(svc.MountPath(), svc.Mux())
+			srv = httptest.NewServer(mux)
+			var ok bool
+			client, ok = health_v1.NewHealthClient(srv.URL, srv.Client()).(health_v1.HealthClient)
+			Expect(ok).To(BeTrue())
+		})
+
+		AfterEach(func() {
+			srv.Close()
+		})
+
+		It("apply to the call only", func() {
+			ctx := context.Background()
+			var h http.Header
+			_, err := client.CheckWithOptions(ctx, &grpc_health_v1.HealthCheckRequest{},
+				blaze.WithHeader("X-Test", "1"), blaze.WithCodecOverride(blaze.NewJSONCodec()), blaze.CaptureHeaders(&h))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(h.Get("X-Echo")).To(Equal("1"))
+			Expect(h.Get("Content-Type")).To(Equal("application/json"))
+
+			By("not affecting later calls with the same context")
+			h = nil
+			_, err = client.CheckWithOptions(ctx, &grpc_health_v1.HealthCheckRequest{}, blaze.CaptureHeaders(&h))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(h.Get("X-Echo")).To(BeEmpty())
+			Expect(h.Get("Content-Type")).To(Equal("application/protobuf"))
+		})
+
+		It("bound the call with the timeout", func() {
+			_, err := client.CheckWithOptions(context.Background(), &grpc_health_v1.HealthCheckRequest{}, blaze.WithTimeout(time.Nanosecond))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorDeadlineExceeded("").Type()))
+		})
+	})
+})
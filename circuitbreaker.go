@@ -0,0 +1,261 @@
+package blaze
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cestus.io/blaze/pkg/blazemetrics"
+)
+
+// CircuitOpenMetaKey is the meta key of errors returned by an open circuit breaker
+const CircuitOpenMetaKey = "circuit_open"
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all calls pass
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all calls
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe calls pass
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOption is a functional option for extending a circuit breaker client
+type CircuitBreakerOption func(*circuitBreakerClient)
+
+// WithFailureThreshold sets the number of consecutive failures opening the circuit
+func WithFailureThreshold(failures int) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.failureThreshold = failures
+	}
+}
+
+// WithOpenTimeout sets the time an open circuit rejects calls before probe calls are let through
+func WithOpenTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenRequests sets the number of successful probe calls closing the circuit
+func WithHalfOpenRequests(requests int) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.halfOpenRequests = requests
+	}
+}
+
+// WithFailureErrorTypes sets the Error.Type() of errors counted as failures e.g. ErrorUnavailable("").Type()
+func WithFailureErrorTypes(types ...string) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.failureTypes = types
+	}
+}
+
+// WithStateChangeCallback sets a function called whenever the circuit of a target changes its state
+func WithStateChangeCallback(cb func(target string, from CircuitState, to CircuitState)) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.onStateChange = cb
+	}
+}
+
+// WithCircuitBreakerMetrics replaces the default metrics
+func WithCircuitBreakerMetrics(metrics blazemetrics.CircuitBreakerMetrics) CircuitBreakerOption {
+	return func(c *circuitBreakerClient) {
+		c.metrics = metrics
+	}
+}
+
+type circuitBreakerClient struct {
+	client           HTTPClient
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenRequests int
+	failureTypes     []string
+	onStateChange    func(target string, from CircuitState, to CircuitState)
+	metrics          blazemetrics.CircuitBreakerMetrics
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	// generation counts the state changes, calls are tagged with the generation they were admitted in
+	generation uint64
+}
+
+type transition struct {
+	target   string
+	from, to CircuitState
+}
+
+// NewCircuitBreakerClient wraps client with a circuit breaker per target URL.
+// After consecutive failures the circuit opens and calls fail fast with an UnavailableErrorType carrying
+// the circuit_open meta. After the open timeout probe calls decide whether the circuit closes again
+func NewCircuitBreakerClient(client HTTPClient, opts ...CircuitBreakerOption) HTTPClient {
+	c := &circuitBreakerClient{
+		client:           client,
+		failureThreshold: 5,
+		openTimeout:      30 * time.Second,
+		halfOpenRequests: 1,
+		failureTypes: []string{
+			ErrorUnavailable("").Type(),
+			ErrorInternal("").Type(),
+			ErrorUnknown("").Type(),
+			ErrorDeadlineExceeded("").Type(),
+			ErrorDataLoss("").Type(),
+		},
+		circuits: map[string]*circuit{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.metrics == nil {
+		c.metrics = blazemetrics.NewCircuitBreakerMetrics()
+	}
+	return c
+}
+
+func (c *circuitBreakerClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	target := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	allowed, generation, changed := c.allow(target)
+	c.notify(ctx, changed)
+	if !allowed {
+		c.metrics.Rejected(ctx, target)
+		return nil, ErrorUnavailable("circuit breaker is open for "+target).WithMeta(CircuitOpenMetaKey, "true")
+	}
+	resp, err := c.client.Do(req)
+	var blerr Error
+	blerr, resp = classifyAttempt(resp, err)
+	// calls given up by the caller tell nothing about the target
+	if ctx.Err() != nil || (blerr != nil && blerr.Type() == ErrorCanceled("").Type()) {
+		c.release(target, generation)
+		return resp, err
+	}
+	c.notify(ctx, c.record(target, generation, blerr == nil || !c.isFailure(blerr)))
+	return resp, err
+}
+
+func (c *circuitBreakerClient) isFailure(blerr Error) bool {
+	for _, t := range c.failureTypes {
+		if blerr.Type() == t {
+			return true
+		}
+	}
+	return false
+}
+
+// allow reports whether a call may pass and the generation of the circuit it is admitted in
+func (c *circuitBreakerClient) allow(target string) (bool, uint64, *transition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cc, ok := c.circuits[target]
+	if !ok {
+		cc = &circuit{}
+		c.circuits[target] = cc
+	}
+	var changed *transition
+	if cc.state == CircuitOpen {
+		if time.Since(cc.openedAt) < c.openTimeout {
+			return false, cc.generation, nil
+		}
+		changed = c.setState(target, cc, CircuitHalfOpen)
+	}
+	if cc.state == CircuitHalfOpen {
+		if cc.inFlight >= c.halfOpenRequests {
+			return false, cc.generation, changed
+		}
+		cc.inFlight++
+	}
+	return true, cc.generation, changed
+}
+
+// record counts the outcome of a call admitted in the generation. Calls admitted before the last
+// state change are ignored, e.g. calls admitted while closed which finish while probes are let through
+func (c *circuitBreakerClient) record(target string, generation uint64, success bool) *transition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cc := c.circuits[target]
+	if generation != cc.generation {
+		return nil
+	}
+	switch cc.state {
+	case CircuitClosed:
+		if success {
+			cc.failures = 0
+			return nil
+		}
+		cc.failures++
+		if cc.failures >= c.failureThreshold {
+			return c.setState(target, cc, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if cc.inFlight > 0 {
+			cc.inFlight--
+		}
+		if !success {
+			return c.setState(target, cc, CircuitOpen)
+		}
+		cc.successes++
+		if cc.successes >= c.halfOpenRequests {
+			return c.setState(target, cc, CircuitClosed)
+		}
+	}
+	return nil
+}
+
+// release frees the probe slot of a call admitted in the generation without counting its outcome
+func (c *circuitBreakerClient) release(target string, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cc := c.circuits[target]
+	if generation == cc.generation && cc.state == CircuitHalfOpen && cc.inFlight > 0 {
+		cc.inFlight--
+	}
+}
+
+// setState must be called with the lock held
+func (c *circuitBreakerClient) setState(target string, cc *circuit, state CircuitState) *transition {
+	t := &transition{target: target, from: cc.state, to: state}
+	cc.state = state
+	cc.generation++
+	cc.failures = 0
+	cc.successes = 0
+	cc.inFlight = 0
+	if state == CircuitOpen {
+		cc.openedAt = time.Now()
+	}
+	return t
+}
+
+func (c *circuitBreakerClient) notify(ctx context.Context, t *transition) {
+	if t == nil {
+		return
+	}
+	c.metrics.StateChanged(ctx, t.target, t.to.String())
+	if c.onStateChange != nil {
+		c.onStateChange(t.target, t.from, t.to)
+	}
+}
@@ -0,0 +1,178 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+// waitFor answers with the attempt once release is closed
+func waitFor(release chan struct{}, a attempt) attempt {
+	return func(req *http.Request) (*http.Response, error) {
+		<-release
+		return a(req)
+	}
+}
+
+var _ = Describe("CircuitBreakerClient", func() {
+	var (
+		inner       *scriptedClient
+		client      blaze.HTTPClient
+		mu          sync.Mutex
+		transitions []string
+	)
+	BeforeEach(func() {
+		inner = &scriptedClient{}
+		transitions = nil
+		client = blaze.NewCircuitBreakerClient(inner,
+			blaze.WithFailureThreshold(2),
+			blaze.WithOpenTimeout(50*time.Millisecond),
+			blaze.WithHalfOpenRequests(1),
+			blaze.WithStateChangeCallback(func(target string, from, to blaze.CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, from.String()+"->"+to.String())
+			}),
+		)
+	})
+	do := func() (*http.Response, error) {
+		req, _ := http.NewRequestWithContext(context.Background(), "POST", "http://localhost/svc/Method", nil)
+		return client.Do(req)
+	}
+	states := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, transitions...)
+	}
+	expectOpen := func() {
+		_, err := do()
+		blerr, ok := err.(blaze.Error)
+		ExpectWithOffset(1, ok).To(BeTrue(), "%v", err)
+		ExpectWithOffset(1, blerr.Meta(blaze.CircuitOpenMetaKey)).To(Equal("true"))
+	}
+
+	It("opens after consecutive failures and rejects calls", func() {
+		inner.attempts = []attempt{respond(503, ""), respond(200, ""), respond(503, ""), respond(503, "")}
+		for i := 0; i < 4; i++ {
+			_, err := do()
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(states()).To(Equal([]string{"closed->open"}))
+		expectOpen()
+		Expect(inner.calls()).To(Equal(4))
+	})
+
+	It("does not count errors of other types", func() {
+		invalid := blazeError(blaze.ErrorInvalidArgument("name", "is empty"))
+		inner.attempts = []attempt{invalid, invalid, respond(200, "")}
+		for i := 0; i < 3; i++ {
+			_, err := do()
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(states()).To(BeEmpty())
+	})
+
+	It("closes after a successful probe", func() {
+		release := make(chan struct{})
+		inner.attempts = []attempt{respond(503, ""), respond(503, ""), waitFor(release, respond(200, "")), respond(200, "")}
+		_, _ = do()
+		_, _ = do()
+		time.Sleep(60 * time.Millisecond)
+		probe := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(probe)
+			_, err := do()
+			Expect(err).ToNot(HaveOccurred())
+		}()
+		Eventually(inner.calls).Should(Equal(3))
+		By("rejecting calls beyond the probes")
+		expectOpen()
+		close(release)
+		Eventually(probe).Should(BeClosed())
+		Expect(states()).To(Equal([]string{"closed->open", "open->half_open", "half_open->closed"}))
+		_, err := do()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("opens again after a failed probe", func() {
+		inner.attempts = []attempt{respond(503, ""), respond(503, ""), respond(503, "")}
+		_, _ = do()
+		_, _ = do()
+		time.Sleep(60 * time.Millisecond)
+		_, err := do()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(states()).To(Equal([]string{"closed->open", "open->half_open", "half_open->open"}))
+		expectOpen()
+	})
+
+	DescribeTable("does not count calls without outcome",
+		func(given attempt, callerDone bool) {
+			inner.attempts = []attempt{respond(503, ""), given, respond(503, ""), given, respond(200, "")}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if callerDone {
+				cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, "POST", "http://localhost/svc/Method", nil)
+
+			By("neither counting a failure nor a success of a closed circuit")
+			_, _ = do()
+			_, _ = client.Do(req)
+			Expect(states()).To(BeEmpty())
+			_, _ = do()
+			Expect(states()).To(Equal([]string{"closed->open"}))
+
+			By("releasing the probe slot of a half open circuit")
+			time.Sleep(60 * time.Millisecond)
+			_, _ = client.Do(req)
+			Expect(states()).To(Equal([]string{"closed->open", "open->half_open"}))
+			_, err := do()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states()).To(Equal([]string{"closed->open", "open->half_open", "half_open->closed"}))
+		},
+		Entry("canceled by the caller", fail(context.Canceled), true),
+		Entry("exceeding the deadline of the caller", fail(context.DeadlineExceeded), true),
+		Entry("canceled by the service", blazeError(blaze.ErrorCanceled("canceled")), false),
+	)
+
+	It("does not count calls admitted while closed as probes", func() {
+		slow, probeRelease := make(chan struct{}), make(chan struct{})
+		inner.attempts = []attempt{
+			waitFor(slow, respond(200, "")),
+			respond(503, ""), respond(503, ""),
+			waitFor(probeRelease, respond(200, "")),
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = do()
+		}()
+		Eventually(inner.calls).Should(Equal(1))
+		_, _ = do()
+		_, _ = do()
+		time.Sleep(60 * time.Millisecond)
+		probe := make(chan struct{})
+		go func() {
+			defer close(probe)
+			_, _ = do()
+		}()
+		Eventually(inner.calls).Should(Equal(4))
+
+		By("finishing the call admitted while closed during the probe")
+		close(slow)
+		Eventually(done).Should(BeClosed())
+		Expect(states()).To(Equal([]string{"closed->open", "open->half_open"}))
+		expectOpen()
+
+		close(probeRelease)
+		Eventually(probe).Should(BeClosed())
+		Expect(states()).To(Equal([]string{"closed->open", "open->half_open", "half_open->closed"}))
+	})
+})
@@ -11,10 +11,13 @@ import (
 	"unicode/utf8"
 
 	"code.cestus.io/blaze/internal/generation/fieldnum"
+	"code.cestus.io/blaze/pkg/blazeoptions"
 	"github.com/go-logr/logr"
 	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/runtime/protoimpl"
+	"google.golang.org/protobuf/types/descriptorpb"
 
 	"google.golang.org/protobuf/types/pluginpb"
 )
@@ -49,11 +52,13 @@ var (
 	contextPackage      goImportPath = protogen.GoImportPath("context")
 	ioPackage           goImportPath = protogen.GoImportPath("io")
 	httpPackage         goImportPath = protogen.GoImportPath("net/http")
+	timePackage         goImportPath = protogen.GoImportPath("time")
 	protoJSONPackage    goImportPath = protogen.GoImportPath("google.golang.org/protobuf/encoding/protojson")
 	logrPackage         goImportPath = protogen.GoImportPath("github.com/go-logr/logr")
 	chiPackage          goImportPath = protogen.GoImportPath("github.com/go-chi/chi/v5")
 	blazePackage        goImportPath = protogen.GoImportPath("code.cestus.io/blaze")
 	blazetracePackage   goImportPath = protogen.GoImportPath("code.cestus.io/blaze/pkg/blazetrace")
+	blazemetricsPackage goImportPath = protogen.GoImportPath("code.cestus.io/blaze/pkg/blazemetrics")
 )
 
 type goImportPath interface {
@@ -269,12 +274,36 @@ func (s *Blaze) generateBlazeInterface(g *protogen.GeneratedFile, file *fileInfo
 	}
 	g.P(`}`)
 }
-func (s *Blaze) generateMethodSignature(g *protogen.GeneratedFile, method *protogen.Method) {
+
+// generateClientInterface generates the interface of the clients of a service. It extends the service
+// interface with a WithOptions variant of each method accepting options for a single call
+func (s *Blaze) generateClientInterface(g *protogen.GeneratedFile, file *fileInfo, service *protogen.Service) {
+	servName := service.GoName
+	g.P(`// `, servName, `Client is implemented by the clients of `, servName, `.`)
+	g.P(`// Its WithOptions methods accept options for a single call.`)
+	g.P(`type `, servName, `Client interface {`)
+	g.P(servName)
+	for _, method := range service.Methods {
+		g.P(`// `, method.GoName, `WithOptions calls `, method.GoName, ` with the options of the call.`)
+		s.generateMethodSignature(g, method, "WithOptions", `...`+g.QualifiedGoIdent(blazePackage.Ident("CallOption")))
+	}
+	g.P(`}`)
+	g.P()
+}
+
+func (s *Blaze) generateMethodSignature(g *protogen.GeneratedFile, method *protogen.Method, extra ...string) {
 	var reqArgs []string
 	reqArgs = append(reqArgs, g.QualifiedGoIdent(contextPackage.Ident("Context")))
 	reqArgs = append(reqArgs, fmt.Sprint("*", g.QualifiedGoIdent(method.Input.GoIdent)))
+	comments := method.Comments.Leading
+	name := method.GoName
+	if len(extra) > 0 {
+		comments = ""
+		name += extra[0]
+		reqArgs = append(reqArgs, extra[1:]...)
+	}
 	ret := "(*" + g.QualifiedGoIdent(method.Output.GoIdent) + ", error)"
-	g.P(method.Comments.Leading, method.GoName+"("+strings.Join(reqArgs, ", ")+") "+ret)
+	g.P(comments, name+"("+strings.Join(reqArgs, ", ")+") "+ret)
 }
 
 // appendDeprecationSuffix optionally appends a deprecation notice as a suffix.
@@ -292,10 +321,9 @@ func (s *Blaze) genService(gen *protogen.Plugin, file *fileInfo, g *protogen.Gen
 	servName := service.GoName
 	s.sectionComment(g, servName+` Interface`)
 	s.generateBlazeInterface(g, file, service)
-	s.sectionComment(g, servName+` Protobuf Client`)
-	s.generateClient("Protobuf", g, file, service)
-	s.sectionComment(g, servName+` JSON Client`)
-	s.generateClient("JSON", g, file, service)
+	s.sectionComment(g, servName+` Client`)
+	s.generateClientInterface(g, file, service)
+	s.generateClient(g, file, service)
 	// Service
 	s.sectionComment(g, servName+` Service`)
 	s.generateServer(g, file, service)
@@ -318,21 +346,25 @@ func (s *Blaze) generateImplementInterface(g *protogen.GeneratedFile, file *file
 	g.P(`}`)
 }
 
-// valid names: 'JSON', 'Protobuf'
-func (s *Blaze) generateClient(name string, g *protogen.GeneratedFile, file *fileInfo, service *protogen.Service) {
+func (s *Blaze) generateClient(g *protogen.GeneratedFile, file *fileInfo, service *protogen.Service) {
 	servName := service.GoName
 	pathPrefixConst := servName + "PathPrefix"
-	structName := unexported(servName) + name + "Client"
-	newClientFunc := "New" + servName + name + "Client"
+	structName := unexported(servName) + "Client"
+	newClientFunc := "New" + servName + "Client"
 	methCnt := strconv.Itoa(len(service.Methods))
 	g.P(`type `, structName, ` struct {`)
 	g.P(`client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")))
 	g.P(`urls   [`, methCnt, `]string`)
 	g.P(`opts `, g.QualifiedGoIdent(blazePackage.Ident("ClientOptions")))
 	g.P(`trace `, g.QualifiedGoIdent(blazetracePackage.Ident("ClientTracer")))
+	g.P(`metrics `, g.QualifiedGoIdent(blazemetricsPackage.Ident("ClientMetrics")))
+	g.P(`codec `, g.QualifiedGoIdent(blazePackage.Ident("Codec")))
+	g.P(`err error`)
 	g.P(`}`)
-	g.P(`// `, newClientFunc, ` creates a `, name, ` client that implements the `, servName, ` interface.`)
-	g.P(`// It communicates using `, name, ` and can be configured with a custom HTTPClient.`)
+	g.P(`// `, newClientFunc, ` creates a client that implements the `, servName, ` interface.`)
+	g.P(`// It communicates using the codec set WithCodec (protobuf by default) and can be configured with a custom HTTPClient.`)
+	g.P(`// The client implements `, servName, `Client, whose WithOptions methods accept options for a single call.`)
+	g.P(`// Calls of a client with invalid options fail with an internal error.`)
 	g.P(`func `, newClientFunc, `(addr string, client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")), `, opts ...`, g.QualifiedGoIdent(blazePackage.Ident("ClientOption")), `) `, servName, ` {`)
 	g.P(`  if c, ok := client.(*`, g.QualifiedGoIdent(httpPackage.Ident("Client")), `); ok {`)
 	g.P(`    client = `, g.QualifiedGoIdent(blazePackage.Ident("WithoutRedirects")), `(c)`)
@@ -340,6 +372,7 @@ func (s *Blaze) generateClient(name string, g *protogen.GeneratedFile, file *fil
 	g.P()
 	g.P(`  clientOpts := `, g.QualifiedGoIdent(blazePackage.Ident("ClientOptions")), `{`)
 	g.P(`    Trace: `, g.QualifiedGoIdent(blazetracePackage.Ident("NewClientTracer")), `(),`)
+	g.P(`    Metrics: `, g.QualifiedGoIdent(blazemetricsPackage.Ident("NewClientMetrics")), `(),`)
 	g.P(`  }`)
 	g.P(`  for _, o := range opts {`)
 	g.P(`    o(&clientOpts)`)
@@ -354,33 +387,64 @@ func (s *Blaze) generateClient(name string, g *protogen.GeneratedFile, file *fil
 	}
 	g.P(`  }`)
 	g.P()
-	g.P(`  return &`, structName, `{`)
+	g.P(`  c := &`, structName, `{`)
 	g.P(`    client: client,`)
 	g.P(`    urls:   urls,`)
 	g.P(`    opts: clientOpts,`)
 	g.P(`    trace: clientOpts.Trace,`)
+	g.P(`    metrics: clientOpts.Metrics,`)
+	g.P(`    codec: clientOpts.ClientCodec(),`)
 	g.P(`  }`)
+	g.P(`  // invalid options fail every call`)
+	g.P(`  if err := clientOpts.Validate(); err != nil {`)
+	g.P(`    c.err = `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "invalid client options: "+err.Error())`)
+	g.P(`  }`)
+	g.P(`  return c`)
 	g.P(`}`)
 	g.P()
+	for _, name := range []string{"Protobuf", "JSON"} {
+		codec := g.QualifiedGoIdent(blazePackage.Ident("ProtobufCodec")) + `{}`
+		if name == "JSON" {
+			codec = g.QualifiedGoIdent(blazePackage.Ident("NewJSONCodec")) + `()`
+		}
+		g.P(`// New`, servName, name, `Client creates a `, name, ` client that implements the `, servName, ` interface.`)
+		g.P(`// It communicates using `, name, ` and can be configured with a custom HTTPClient.`)
+		g.P(`func New`, servName, name, `Client(addr string, client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")), `, opts ...`, g.QualifiedGoIdent(blazePackage.Ident("ClientOption")), `) `, servName, ` {`)
+		g.P(`  return `, newClientFunc, `(addr, client, append([]`, g.QualifiedGoIdent(blazePackage.Ident("ClientOption")), `{`, g.QualifiedGoIdent(blazePackage.Ident("WithCodec")), `(`, codec, `)}, opts...)...)`)
+		g.P(`}`)
+		g.P()
+	}
+
 	for i, method := range service.Methods {
 		methName := method.GoName
-		//pkgName := pkgName(file)
 		servName := service.GoName
-		// inputType := s.goTypeName(method.Input.GoIdent.)
-		// outputType := s.goTypeName(method.GetOutputType())
 
 		g.P(`func (s *`, structName, `) `, methName, `(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, in *`, g.QualifiedGoIdent(method.Input.GoIdent), `) (*`, g.QualifiedGoIdent(method.Output.GoIdent), `, error) {`)
+		g.P(`  return s.`, methName, `WithOptions(ctx, in)`)
+		g.P(`}`)
+		g.P()
+		g.P(`func (s *`, structName, `) `, methName, `WithOptions(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, in *`, g.QualifiedGoIdent(method.Input.GoIdent), `, opts ...`, g.QualifiedGoIdent(blazePackage.Ident("CallOption")), `) (*`, g.QualifiedGoIdent(method.Output.GoIdent), `, error) {`)
+		g.P(`  if s.err != nil {`)
+		g.P(`    return nil, s.err`)
+		g.P(`  }`)
+		g.P(`  callOpts := `, g.QualifiedGoIdent(blazePackage.Ident("NewCallOptions")), `(opts...)`)
+		g.P(`  ctx, cancel := callOpts.Context(ctx)`)
+		g.P(`  defer cancel()`)
 		g.P(`  ctx, span := s.trace.StartSpan(ctx, "`, methName, `", `, g.QualifiedGoIdent(blazetracePackage.Ident("WithAttributes")), `(`, g.QualifiedGoIdent(blazetracePackage.Ident("ClientName")), `.String("`, servName, `")))`)
 		g.P(`  ctx = s.trace.AnnotateWithClientTrace(ctx)`)
 		g.P(`  defer s.trace.EndSpan(span)`)
+		g.P(`  ctx = `, g.QualifiedGoIdent(blazePackage.Ident("WithMethodInfo")), `(ctx, `, g.QualifiedGoIdent(blazePackage.Ident("MethodInfo")), `{Service: "`, servName, `", Method: "`, methName, `", Idempotency: `, g.QualifiedGoIdent(blazePackage.Ident(idempotencyLevel(method))), `})`)
+		g.P(`  ctx, call := s.metrics.StartCall(ctx, "`, servName, `", "`, methName, `", callOpts.CodecOr(s.codec).Name())`)
+		g.P(`  defer s.metrics.EndCall(ctx, call)`)
 		g.P(`  out := new(`, g.QualifiedGoIdent(method.Output.GoIdent), `)`)
-		g.P(`  err := s.do`, name, `Request(ctx, s.client, s.urls[`, strconv.Itoa(i), `], in, out)`)
+		g.P(`  err := s.doRequest(ctx, s.client, s.urls[`, strconv.Itoa(i), `], in, out, callOpts)`)
 		g.P(`  if err != nil {`)
 		g.P(`    blerr, ok := err.(`, g.QualifiedGoIdent(blazePackage.Ident("Error")), `)`)
 		g.P(`    if !ok {`)
 		g.P(`      blerr = `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err,"")`)
 		g.P(`    }`)
 		g.P(`  span.SetStatus(`, g.QualifiedGoIdent(blazePackage.Ident("OtelCodeFromErrorType")), `(blerr),blerr.Error())`, "")
+		g.P(`    call.SetError(blerr)`)
 		g.P(`    return nil, blerr`)
 		g.P(`  }`)
 		g.P(`  span.SetStatus(`, g.QualifiedGoIdent(blazePackage.Ident("OtelCodeFromErrorType")), `(nil),"")`, "")
@@ -388,110 +452,71 @@ func (s *Blaze) generateClient(name string, g *protogen.GeneratedFile, file *fil
 		g.P(`}`)
 		g.P()
 	}
-	if name == "Protobuf" {
-		g.P(`// doProtobufRequest makes a Protobuf request to the remote Blaze service.`)
-		g.P(`func (s *`, structName, `) doProtobufRequest(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")), `, url string, in, out `, g.QualifiedGoIdent(protoPackage.Ident("Message")), `) (err error) {`)
-		g.P(`  reqBodyBytes, err := `, g.QualifiedGoIdent(protoPackage.Ident("Marshal")), `(in)`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal proto request")`)
-		g.P(`  }`)
-		g.P(`  reqBody := `, g.QualifiedGoIdent(bytesPackage.Ident("NewBuffer")), `(reqBodyBytes)`)
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  req, err := `, g.QualifiedGoIdent(blazePackage.Ident("NewHTTPRequest")), `(ctx, url, reqBody, "application/protobuf","`, s.version, `")`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "could not build request")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  resp, err := client.Do(req)`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to do request")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  defer func() {`)
-		g.P(`    cerr := resp.Body.Close()`)
-		g.P(`    if err == nil && cerr != nil {`)
-		g.P(`      err = `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(cerr, "failed to close response body")`)
-		g.P(`    }`)
-		g.P(`  }()`)
-		g.P()
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  if resp.StatusCode != 200 {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorFromResponse")), `(resp)`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  respBodyBytes, err := `, g.QualifiedGoIdent(ioPackage.Ident("ReadAll")), `(resp.Body)`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to read response body")`)
-		g.P(`  }`)
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  if err = `, g.QualifiedGoIdent(protoPackage.Ident("Unmarshal")), `(respBodyBytes, out); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to unmarshal proto response")`)
-		g.P(`  }`)
-		g.P(`  return nil`)
-		g.P(`}`)
-		g.P()
-	}
-
-	if name == "JSON" {
-		g.P(`// doJSONRequest makes a JSON request to the remote Blaze service.`)
-		g.P(`func (s *`, structName, `) doJSONRequest(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")), `, url string, in, out `, g.QualifiedGoIdent(protoPackage.Ident("Message")), `) (err error) {`)
-		g.P(`var buf []byte`)
-		g.P(`marshaler := &`, g.QualifiedGoIdent(protoJSONPackage.Ident("MarshalOptions")), `{UseProtoNames: true}`)
-		g.P(`if buf, err = marshaler.Marshal(in); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal json request")`)
-		g.P(`  }`)
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  req, err := `, g.QualifiedGoIdent(blazePackage.Ident("NewHTTPRequest")), `(ctx, url,`, g.QualifiedGoIdent(bytesPackage.Ident("NewReader")), `(buf), "application/json","`, s.version, `")`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "could not build request")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  resp, err := client.Do(req)`)
-		g.P(`  if err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to do request")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  defer func() {`)
-		g.P(`    cerr := resp.Body.Close()`)
-		g.P(`    if err == nil && cerr != nil {`)
-		g.P(`      err = `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(cerr, "failed to close response body")`)
-		g.P(`    }`)
-		g.P(`  }()`)
-		g.P()
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P()
-		g.P(`  if resp.StatusCode != 200 {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorFromResponse")), `(resp)`)
-		g.P(`  }`)
-		g.P()
-		g.P(`respByte, err :=`, g.QualifiedGoIdent(ioPackage.Ident("ReadAll")), `(resp.Body)`)
-		g.P(`if err != nil {`)
-		g.P(`return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to read response body")`)
-		g.P(`}`)
-		g.P(`  unmarshaler := `, g.QualifiedGoIdent(protoJSONPackage.Ident("UnmarshalOptions")), `{DiscardUnknown: true}`)
-		g.P(`  if err = unmarshaler.Unmarshal(respByte, out); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to unmarshal json response")`)
-		g.P(`  }`)
-		g.P(`  if err = ctx.Err(); err != nil {`)
-		g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "aborted because context was done")`)
-		g.P(`  }`)
-		g.P(`  return nil`)
-		g.P(`}`)
-	}
+	g.P(`// doRequest makes a request to the remote Blaze service encoded with the codec of the call or the client.`)
+	g.P(`func (s *`, structName, `) doRequest(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, client `, g.QualifiedGoIdent(blazePackage.Ident("HTTPClient")), `, url string, in, out `, g.QualifiedGoIdent(protoPackage.Ident("Message")), `, callOpts *`, g.QualifiedGoIdent(blazePackage.Ident("CallOptions")), `) (err error) {`)
+	g.P(`  codec := callOpts.CodecOr(s.codec)`)
+	g.P(`  reqBodyBytes, err := codec.Marshal(in)`)
+	g.P(`  if err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal `, `request")`)
+	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazemetricsPackage.Ident("CallFromContext")), `(ctx).AddRequestSize(int64(len(reqBodyBytes)))`)
+	g.P(`  if err = ctx.Err(); err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ClientErrorFromTransport")), `(err, "aborted because context was done")`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  var req *`, g.QualifiedGoIdent(httpPackage.Ident("Request")))
+	g.P(`  if info, _ := `, g.QualifiedGoIdent(blazePackage.Ident("MethodInfoFromContext")), `(ctx); info.Idempotency == `, g.QualifiedGoIdent(blazePackage.Ident("NoSideEffects")), ` {`)
+	g.P(`    req, err = `, g.QualifiedGoIdent(blazePackage.Ident("NewGetHTTPRequest")), `(ctx, url, reqBodyBytes, codec, "`, s.version, `")`)
+	g.P(`  } else {`)
+	g.P(`    req, err = `, g.QualifiedGoIdent(blazePackage.Ident("NewHTTPRequest")), `(ctx, url, `, g.QualifiedGoIdent(bytesPackage.Ident("NewReader")), `(reqBodyBytes), codec.ContentTypes()[0], "`, s.version, `")`)
+	g.P(`  }`)
+	g.P(`  if err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "could not build request")`)
+	g.P(`  }`)
+	g.P(`  if err = `, g.QualifiedGoIdent(blazePackage.Ident("AttachCredentials")), `(ctx, req, s.opts); err != nil {`)
+	g.P(`    return err`)
+	g.P(`  }`)
+	g.P(`  callOpts.PrepareRequest(req)`)
+	g.P(`  if err = `, g.QualifiedGoIdent(blazePackage.Ident("CompressRequest")), `(req, reqBodyBytes, s.opts); err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to compress request")`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  resp, err := client.Do(req)`)
+	g.P(`  if err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ClientErrorFromTransport")), `(err, "failed to do request")`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  defer func() {`)
+	g.P(`    cerr := resp.Body.Close()`)
+	g.P(`    if err == nil && cerr != nil {`)
+	g.P(`      err = `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(cerr, "failed to close response body")`)
+	g.P(`    }`)
+	g.P(`    callOpts.CaptureResponse(resp)`)
+	g.P(`  }()`)
+	g.P()
+	g.P(`  if err = ctx.Err(); err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ClientErrorFromTransport")), `(err, "aborted because context was done")`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  if resp.StatusCode != 200 {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorFromResponse")), `(resp)`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  respBodyBytes, err := `, g.QualifiedGoIdent(blazePackage.Ident("ReadResponseBody")), `(resp)`)
+	g.P(`  if err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ClientErrorFromTransport")), `(err, "failed to read response body")`)
+	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazemetricsPackage.Ident("CallFromContext")), `(ctx).AddResponseSize(int64(len(respBodyBytes)))`)
+	g.P(`  if err = ctx.Err(); err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ClientErrorFromTransport")), `(err, "aborted because context was done")`)
+	g.P(`  }`)
+	g.P()
+	g.P(`  if err = codec.Unmarshal(respBodyBytes, out); err != nil {`)
+	g.P(`    return `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to unmarshal response")`)
+	g.P(`  }`)
+	g.P(`  return nil`)
+	g.P(`}`)
+	g.P()
 }
 func (s *Blaze) generateServerSample(g *protogen.GeneratedFile, file *fileInfo, service *protogen.Service) {
 	//servName := service.GoName
@@ -529,6 +554,7 @@ func (s *Blaze) generateServer(g *protogen.GeneratedFile, file *fileInfo, servic
 	g.P(`  `, "mux *", g.QualifiedGoIdent(chiPackage.Ident("Mux")))
 	g.P(`  `, "mountPath string")
 	g.P(`     serviceTracer `, g.QualifiedGoIdent(blazetracePackage.Ident("ServiceTracer")))
+	g.P(`     serviceMetrics `, g.QualifiedGoIdent(blazemetricsPackage.Ident("ServiceMetrics")))
 	g.P(`     serviceOptions `, g.QualifiedGoIdent(blazePackage.Ident("ServiceOptions")))
 	g.P(`}`)
 	g.P()
@@ -537,6 +563,7 @@ func (s *Blaze) generateServer(g *protogen.GeneratedFile, file *fileInfo, servic
 	g.P(`func New`, servName, `Service(svc `, servName, `, log `, g.QualifiedGoIdent(logrPackage.Ident("Logger")), `, opts ...`, g.QualifiedGoIdent(blazePackage.Ident("ServiceOption")), `) `, g.QualifiedGoIdent(blazePackage.Ident("Service")), ` {`)
 	g.P(`	serviceOptions := `, g.QualifiedGoIdent(blazePackage.Ident("ServiceOptions")), `{`)
 	g.P(`   Trace: `, g.QualifiedGoIdent(blazetracePackage.Ident("NewServiceTracer")), `(),`)
+	g.P(`   Metrics: `, g.QualifiedGoIdent(blazemetricsPackage.Ident("NewServiceMetrics")), `(),`)
 	g.P(`   }`)
 	g.P(`	for _, o := range opts {`)
 	g.P(`		o(&serviceOptions)`)
@@ -554,12 +581,17 @@ func (s *Blaze) generateServer(g *protogen.GeneratedFile, file *fileInfo, servic
 	g.P(`		serviceOptions: serviceOptions,`)
 	g.P(`		mountPath:     `, servName, `PathPrefix,`)
 	g.P(`   	serviceTracer:  serviceOptions.Trace,`)
+	g.P(`   	serviceMetrics: serviceOptions.Metrics,`)
 	g.P(`       `, servName, `: svc,`)
 	g.P(`}`)
 	g.P(`r.Use(service.serviceTracer.TracingMiddleware("`, servName, `"))`)
+	g.P(`r.Use(service.serviceMetrics.Middleware("`, servName, `"))`)
 	for _, method := range service.Methods {
 		methName := "serve" + method.GoName
 		g.P(`r.Post("/`, method.GoName, `",service.`, methName, `)`)
+		if idempotencyLevel(method) == "NoSideEffects" {
+			g.P(`r.Get("/`, method.GoName, `",service.`, methName, `)`)
+		}
 	}
 	g.P(`return &service`)
 	g.P(`}`)
@@ -567,6 +599,8 @@ func (s *Blaze) generateServer(g *protogen.GeneratedFile, file *fileInfo, servic
 	// Getting the mount path as package/version
 	ss := strings.ReplaceAll(string(file.GoPackageName), "_", "/")
 	g.P(`const `, servName, `PathPrefix = "/`, ss, `"`)
+	g.P()
+	s.generateAuthorizationTable(g, service)
 
 	g.P(`// Methods.`)
 	for _, method := range service.Methods {
@@ -577,122 +611,53 @@ func (s *Blaze) generateServer(g *protogen.GeneratedFile, file *fileInfo, servic
 func (s *Blaze) generateServerMethod(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) {
 	methName := method.GoName
 	servStruct := serviceStruct(service)
-	//servName := service.GoName
+	servName := service.GoName
 	g.P(`func (s *`, servStruct, `) serve`, methName, `(resp `, g.QualifiedGoIdent(httpPackage.Ident("ResponseWriter")), `, req *`, g.QualifiedGoIdent(httpPackage.Ident("Request")), `) {`)
 	g.P(`ctx := req.Context()`)
 	g.P(`ctx = s.serviceTracer.InjectTracer(ctx)`)
-	//g.P(`  ctx, req, psd := s.serviceTracer.Extract(req)`)
-	//g.P(`  ctx, span := s.serviceTracer.StartSpan(ctx, "`, methName, `", psd,  `, g.QualifiedGoIdent(blazetracePackage.Ident("WithAttributes")), `( `, g.QualifiedGoIdent(blazetracePackage.Ident("ServiceName")), `.String("`, servName, `")))`)
-	//g.P(`  defer s.serviceTracer.EndSpan(span)`)
-	g.P(`  header := req.Header.Get("Content-Type")`)
-	g.P(`  i := `, g.QualifiedGoIdent(stringsPackage.Ident("Index")), `(header, ";")`)
-	g.P(`  if i == -1 {`)
-	g.P(`    i = len(header)`)
-	g.P(`  }`)
-	g.P(`  switch `, g.QualifiedGoIdent(stringsPackage.Ident("TrimSpace")), `(`, g.QualifiedGoIdent(stringsPackage.Ident("ToLower")), `(header[:i])) {`)
-	g.P(`  case "application/json":`)
-	g.P(`    s.serve`, methName, `JSON(ctx, resp, req)`)
-	g.P(`  case "application/protobuf":`)
-	g.P(`    s.serve`, methName, `Protobuf(ctx, resp, req)`)
-	g.P(`  default:`)
-	g.P(`    msg := `, g.QualifiedGoIdent(fmtPackage.Ident("Sprintf")), `("unexpected Content-Type: %q", req.Header.Get("Content-Type"))`)
-	g.P(`    blerr := `, g.QualifiedGoIdent(blazePackage.Ident("ServerInvalidRequestError")), `("Content-Type",msg, req.Method, req.URL.Path)`)
-	g.P(g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, blerr, s.log)`)
-	g.P(`  }`)
-	g.P(`}`)
-	g.P()
-	s.generateServerJSONMethod(g, service, method)
-	s.generateServerProtobufMethod(g, service, method)
-}
-
-func (s *Blaze) generateServerJSONMethod(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) {
-	methName := method.GoName
-	servStruct := serviceStruct(service)
-	servName := service.GoName
-	g.P(`func (s *`, servStruct, `) serve`, methName, `JSON(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, resp `, g.QualifiedGoIdent(httpPackage.Ident("ResponseWriter")), `, req *`, g.QualifiedGoIdent(httpPackage.Ident("Request")), `) {`)
-	g.P(`  var err error`)
-	g.P()
-	g.P(`  reqContent := new(`, g.QualifiedGoIdent(method.Input.GoIdent), `)`)
-	g.P(``)
-	g.P(`respByte, err := `, g.QualifiedGoIdent(ioPackage.Ident("ReadAll")), `(req.Body)`)
-	g.P(`  if err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorMalformed")), `("the json request could not be decoded"), s.log)`)
-	g.P(`    return`)
-	g.P(`  }`)
-	g.P(`  unmarshaler := `, g.QualifiedGoIdent(protoJSONPackage.Ident("UnmarshalOptions")), `{DiscardUnknown: true}`)
-	g.P(`  if err = unmarshaler.Unmarshal(respByte, reqContent); err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorMalformed")), `("the json request could not be decoded"), s.log)`)
-	g.P(`    return`)
+	g.P(`  ctx, span := s.serviceTracer.StartSpan(ctx, "`, servName, `/`, methName, `", `, g.QualifiedGoIdent(blazetracePackage.Ident("WithAttributes")), `(`, g.QualifiedGoIdent(blazetracePackage.Ident("RPCSystemBlaze")), `, `, g.QualifiedGoIdent(blazetracePackage.Ident("RPCServiceKey")), `.String("`, servName, `"), `, g.QualifiedGoIdent(blazetracePackage.Ident("RPCMethodKey")), `.String("`, methName, `")))`)
+	g.P(`  defer s.serviceTracer.EndSpan(span)`)
+	g.P(`  ctx = `, g.QualifiedGoIdent(blazePackage.Ident("WithRequestLogger")), `(ctx, s.log, "`, servName, `", "`, methName, `")`)
+	g.P(`  ctx = `, g.QualifiedGoIdent(blazePackage.Ident("WithServerMetadata")), `(ctx, req)`)
+	g.P(`  codec, codecErr := s.serviceOptions.RequestCodec(req)`)
+	g.P(`  if codecErr == nil {`)
+	g.P(`    `, g.QualifiedGoIdent(blazemetricsPackage.Ident("CallFromContext")), `(ctx).SetContentType(codec.Name())`)
 	g.P(`  }`)
-	g.P()
-	g.P(`  // Call service method`)
-	g.P(`  var respContent *`, g.QualifiedGoIdent(method.Output.GoIdent))
-	g.P(`  func() {`)
-	g.P(`    defer `, g.QualifiedGoIdent(blazePackage.Ident("ServerEnsurePanicResponses")), `(ctx, resp, s.log)`)
-	g.P(`    respContent, err = s.`, servName, `.`, methName, `(ctx, reqContent)`)
-	g.P(`  }()`)
-	g.P()
+	g.P(`  respCodec := s.serviceOptions.ResponseCodec(req.Header.Get("Accept"), codec)`)
+	g.P(`  ctx = `, g.QualifiedGoIdent(blazePackage.Ident("WithResponseCodec")), `(ctx, respCodec)`)
+	g.P(`  ctx, cancel, err := `, g.QualifiedGoIdent(blazePackage.Ident("ServerContextWithTimeout")), `(ctx, req, s.serviceOptions.MaxTimeout)`)
+	g.P(`  defer cancel()`)
 	g.P(`  if err != nil {`)
 	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, err, s.log)`)
 	g.P(`    return`)
 	g.P(`  }`)
-	g.P(`  if respContent == nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternal")), `("received a nil response. nil responses are not supported"), s.log)`)
-	g.P(`    return`)
-	g.P(`  }`)
-	g.P()
-	g.P()
-	g.P(`  var buf []byte`)
-	g.P(`  marshaler := &`, g.QualifiedGoIdent(protoJSONPackage.Ident("MarshalOptions")), `{`)
-	g.P(`		UseProtoNames:    true,`)
-	g.P(`		UseEnumNumbers: s.serviceOptions.JSONEnumsAsInts,`)
-	g.P(`		EmitUnpopulated: s.serviceOptions.JSONEmitDefaults,`)
-	g.P(`  }`)
-	g.P(`  if buf, err = marshaler.Marshal(respContent); err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal json response"), s.log)`)
+	g.P(`  if codecErr != nil {`)
+	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, codecErr, s.log)`)
 	g.P(`    return`)
 	g.P(`  }`)
 	g.P()
-	g.P(`  resp.Header().Set("Content-Type", "application/json")`)
-	g.P(`  resp.Header().Set("Content-Length",`, g.QualifiedGoIdent(strconvPackage.Ident("Itoa")), `(len(buf)))`)
-	g.P(`  resp.WriteHeader(`, g.QualifiedGoIdent(httpPackage.Ident("StatusOK")), `)`)
-	g.P()
-	g.P(`  if n, err := resp.Write(buf); err != nil {`)
-	g.P(`    msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(buf), err.Error())`)
-	g.P(`    blerr := `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternal")), `(msg)`)
-	g.P(`    s.log.Error(blerr, msg)`)
-	g.P(`  }`)
-	g.P(`}`)
-	g.P()
-}
-func (s *Blaze) generateServerProtobufMethod(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) {
-	methName := method.GoName
-	servStruct := serviceStruct(service)
-	servName := service.GoName
-	g.P(`func (s *`, servStruct, `) serve`, methName, `Protobuf(ctx `, g.QualifiedGoIdent(contextPackage.Ident("Context")), `, resp `, g.QualifiedGoIdent(httpPackage.Ident("ResponseWriter")), `, req *`, g.QualifiedGoIdent(httpPackage.Ident("Request")), `) {`)
-	g.P(`  var err error`)
+	g.P(`  decodeStart := `, g.QualifiedGoIdent(timePackage.Ident("Now")), `()`)
+	g.P(`  buf, err := `, g.QualifiedGoIdent(blazePackage.Ident("ReadRequest")), `(resp, req, codec, `, maxRequestBytes(method), `)`)
 	g.P(`  if err != nil {`)
 	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, err, s.log)`)
 	g.P(`    return`)
 	g.P(`  }`)
-	g.P()
-	g.P(`  buf, err := `, g.QualifiedGoIdent(ioPackage.Ident("ReadAll")), `(req.Body)`)
-	g.P(`  if err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to read request body"), s.log)`)
-	g.P(`    return`)
-	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazemetricsPackage.Ident("CallFromContext")), `(ctx).AddRequestSize(int64(len(buf)))`)
 	g.P(`  reqContent := new(`, g.QualifiedGoIdent(method.Input.GoIdent), `)`)
-	g.P(`  if err = `, g.QualifiedGoIdent(protoPackage.Ident("Unmarshal")), `(buf, reqContent); err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorMalformed")), `("the protobuf request could not be decoded"), s.log)`)
+	g.P(`  if err = codec.Unmarshal(buf, reqContent); err != nil {`)
+	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ServerDecodeError")), `(codec, err, buf), s.log)`)
 	g.P(`    return`)
 	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazetracePackage.Ident("MessageReceived")), `(ctx, len(buf), `, g.QualifiedGoIdent(timePackage.Ident("Since")), `(decodeStart))`)
 	g.P()
 	g.P(`  // Call service method`)
 	g.P(`  var respContent *`, g.QualifiedGoIdent(method.Output.GoIdent))
 	g.P(`  func() {`)
-	g.P(`    defer  `, g.QualifiedGoIdent(blazePackage.Ident("ServerEnsurePanicResponses")), `(ctx, resp, s.log)`)
+	g.P(`    defer `, g.QualifiedGoIdent(blazePackage.Ident("ServerEnsurePanicResponses")), `(ctx, resp, s.log)`)
 	g.P(`    respContent, err = s.`, servName, `.`, methName, `(ctx, reqContent)`)
 	g.P(`  }()`)
+	g.P(`  // the client no longer waits for the response once the deadline expired`)
+	g.P(`  err = `, g.QualifiedGoIdent(blazePackage.Ident("ServerDeadlineError")), `(ctx, err)`)
 	g.P()
 	g.P(`  if err != nil {`)
 	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, err, s.log)`)
@@ -703,23 +668,103 @@ func (s *Blaze) generateServerProtobufMethod(g *protogen.GeneratedFile, service
 	g.P(`    return`)
 	g.P(`  }`)
 	g.P()
-	g.P(`  respBytes, err := `, g.QualifiedGoIdent(protoPackage.Ident("Marshal")), `(respContent)`)
+	g.P(`  encodeStart := `, g.QualifiedGoIdent(timePackage.Ident("Now")), `()`)
+	g.P(`  respBytes, err := respCodec.Marshal(respContent)`)
 	g.P(`  if err != nil {`)
-	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal proto response"), s.log)`)
+	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to marshal "+respCodec.Name()+" response"), s.log)`)
 	g.P(`    return`)
 	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazemetricsPackage.Ident("CallFromContext")), `(ctx).AddResponseSize(int64(len(respBytes)))`)
+	g.P(`  `, g.QualifiedGoIdent(blazetracePackage.Ident("MessageSent")), `(ctx, len(respBytes), `, g.QualifiedGoIdent(timePackage.Ident("Since")), `(encodeStart))`)
 	g.P()
-	g.P(`  resp.Header().Set("Content-Type", "application/protobuf")`)
-	g.P(`  resp.Header().Set("Content-Length", strconv.Itoa(len(respBytes)))`)
+	g.P(`  respBody, encoding, err := `, g.QualifiedGoIdent(blazePackage.Ident("CompressResponseBody")), `(req, respBytes, s.serviceOptions)`)
+	g.P(`  if err != nil {`)
+	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("ServerWriteError")), `(ctx, resp, `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternalWith")), `(err, "failed to compress response"), s.log)`)
+	g.P(`    return`)
+	g.P(`  }`)
+	g.P(`  if encoding != "" {`)
+	g.P(`    resp.Header().Set("Content-Encoding", encoding)`)
+	g.P(`  }`)
+	g.P(`  `, g.QualifiedGoIdent(blazePackage.Ident("WriteResponseMetadata")), `(ctx, resp)`)
+	g.P(`  if `, g.QualifiedGoIdent(blazePackage.Ident("ServerNotModified")), `(resp, req) {`)
+	g.P(`    return`)
+	g.P(`  }`)
+	g.P(`  resp.Header().Add("Vary", "Accept")`)
+	g.P(`  resp.Header().Add("Vary", "Accept-Encoding")`)
+	g.P(`  resp.Header().Set("Content-Type", respCodec.ContentTypes()[0])`)
+	g.P(`  `, g.QualifiedGoIdent(blazePackage.Ident("SetContentLength")), `(resp, len(respBody))`)
 	g.P(`  resp.WriteHeader(`, g.QualifiedGoIdent(httpPackage.Ident("StatusOK")), `)`)
-	g.P(`  if n, err := resp.Write(respBytes); err != nil {`)
-	g.P(`    msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBytes), err.Error())`)
+	g.P()
+	g.P(`  if n, err := resp.Write(respBody); err != nil {`)
+	g.P(`    msg := `, g.QualifiedGoIdent(fmtPackage.Ident("Sprintf")), `("failed to write response, %d of %d bytes written: %s", n, len(respBody), err.Error())`)
 	g.P(`    blerr := `, g.QualifiedGoIdent(blazePackage.Ident("ErrorInternal")), `(msg)`)
-	g.P(`    s.log.Error(blerr, msg)`)
+	g.P(`    `, g.QualifiedGoIdent(blazePackage.Ident("LoggerFromContext")), `(ctx).Error(blerr, msg)`)
 	g.P(`  }`)
 	g.P(`}`)
 	g.P()
 }
+
+// maxRequestBytes returns the request body limit of the method. The limit of the service is used
+// unless the method sets max_request_bytes in its blaze options
+func maxRequestBytes(method *protogen.Method) string {
+	opts, ok := proto.GetExtension(method.Desc.Options(), blazeoptions.E_Method).(*blazeoptions.MethodOptions)
+	if !ok || opts.GetMaxRequestBytes() == 0 {
+		return "s.serviceOptions.MaxRequestBytes"
+	}
+	return strconv.FormatInt(opts.GetMaxRequestBytes(), 10)
+}
+
+// generateAuthorizationTable generates the table of the scopes and roles the methods of the service declare
+// in the blaze method option. Methods without requirements are left out
+func (s *Blaze) generateAuthorizationTable(g *protogen.GeneratedFile, service *protogen.Service) {
+	servName := service.GoName
+	g.P(`// `, servName, `Authorization lists the scopes and roles required by the methods of `, servName, `.`)
+	g.P(`var `, servName, `Authorization = `, g.QualifiedGoIdent(blazePackage.Ident("AuthorizationTable")), `{`)
+	for _, method := range service.Methods {
+		opts, ok := proto.GetExtension(method.Desc.Options(), blazeoptions.E_Method).(*blazeoptions.MethodOptions)
+		if !ok || (len(opts.GetScopes()) == 0 && len(opts.GetRoles()) == 0 && !opts.GetPublic()) {
+			continue
+		}
+		var fields []string
+		if len(opts.GetScopes()) > 0 {
+			fields = append(fields, `Scopes: []string{`+quoteAll(opts.GetScopes())+`}`)
+		}
+		if len(opts.GetRoles()) > 0 {
+			fields = append(fields, `Roles: []string{`+quoteAll(opts.GetRoles())+`}`)
+		}
+		if opts.GetPublic() {
+			fields = append(fields, `Public: true`)
+		}
+		g.P(`  `, servName, `PathPrefix + "/`, method.GoName, `": {`, strings.Join(fields, ", "), `},`)
+	}
+	g.P(`}`)
+	g.P()
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// idempotencyLevel returns the blaze constant of the idempotency_level option of the method
+func idempotencyLevel(method *protogen.Method) string {
+	opts, ok := method.Desc.Options().(*descriptorpb.MethodOptions)
+	if !ok {
+		return "IdempotencyUnknown"
+	}
+	switch opts.GetIdempotencyLevel() {
+	case descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+		return "NoSideEffects"
+	case descriptorpb.MethodOptions_IDEMPOTENT:
+		return "Idempotent"
+	default:
+		return "IdempotencyUnknown"
+	}
+}
+
 func unexported(s string) string { return strings.ToLower(s[:1]) + s[1:] }
 
 func serviceStruct(service *protogen.Service) string {
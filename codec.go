@@ -0,0 +1,258 @@
+package blaze
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// CodecNameProtobuf is the name of the protobuf codec
+	CodecNameProtobuf = "protobuf"
+	// CodecNameJSON is the name of the json codec
+	CodecNameJSON = "json"
+)
+
+// Codec encodes messages for one or more content types
+type Codec interface {
+	// Name identifies the codec e.g. in metrics
+	Name() string
+	// ContentTypes lists the media types handled by the codec. The first one is used for requests and responses
+	ContentTypes() []string
+	// Marshal encodes the message
+	Marshal(m proto.Message) ([]byte, error)
+	// Unmarshal decodes data into the message
+	Unmarshal(data []byte, m proto.Message) error
+}
+
+// ProtobufCodec encodes messages in the protobuf wire format
+type ProtobufCodec struct{}
+
+// Name returns protobuf
+func (ProtobufCodec) Name() string { return CodecNameProtobuf }
+
+// ContentTypes returns application/protobuf and application/x-protobuf
+func (ProtobufCodec) ContentTypes() []string {
+	return []string{"application/protobuf", "application/x-protobuf"}
+}
+
+// Marshal encodes the message
+func (ProtobufCodec) Marshal(m proto.Message) ([]byte, error) { return proto.Marshal(m) }
+
+// Unmarshal decodes data into the message
+func (ProtobufCodec) Unmarshal(data []byte, m proto.Message) error { return proto.Unmarshal(data, m) }
+
+// JSONCodec encodes messages with protojson
+type JSONCodec struct {
+	MarshalOptions   protojson.MarshalOptions
+	UnmarshalOptions protojson.UnmarshalOptions
+}
+
+// NewJSONCodec creates a json codec using proto field names and ignoring unknown fields
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{
+		MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}
+}
+
+// Name returns json
+func (c *JSONCodec) Name() string { return CodecNameJSON }
+
+// ContentTypes returns application/json
+func (c *JSONCodec) ContentTypes() []string { return []string{"application/json"} }
+
+// Marshal encodes the message
+func (c *JSONCodec) Marshal(m proto.Message) ([]byte, error) { return c.MarshalOptions.Marshal(m) }
+
+// Unmarshal decodes data into the message
+func (c *JSONCodec) Unmarshal(data []byte, m proto.Message) error {
+	return c.UnmarshalOptions.Unmarshal(data, m)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	byName        map[string]Codec
+	byContentType map[string]Codec
+}{
+	byName:        map[string]Codec{},
+	byContentType: map[string]Codec{},
+}
+
+func init() {
+	_ = RegisterCodec(ProtobufCodec{})
+	_ = RegisterCodec(NewJSONCodec())
+}
+
+// RegisterCodec makes a codec available to all generated services and clients.
+// It fails if a registered codec already has the name or one of the content types of the codec
+func RegisterCodec(c Codec) error {
+	codecs.Lock()
+	defer codecs.Unlock()
+	if _, ok := codecs.byName[c.Name()]; ok {
+		return fmt.Errorf("a codec named %q is already registered", c.Name())
+	}
+	for _, ct := range c.ContentTypes() {
+		if other, ok := codecs.byContentType[strings.ToLower(ct)]; ok {
+			return fmt.Errorf("content type %q is already registered by codec %q", ct, other.Name())
+		}
+	}
+	codecs.byName[c.Name()] = c
+	for _, ct := range c.ContentTypes() {
+		codecs.byContentType[strings.ToLower(ct)] = c
+	}
+	return nil
+}
+
+// CodecByName returns the registered codec with the given name
+func CodecByName(name string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.byName[name]
+	return c, ok
+}
+
+// CodecForContentType returns the registered codec of a Content-Type header. Parameters like charset are ignored
+func CodecForContentType(header string) (Codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.byContentType[mediaType(header)]
+	return c, ok
+}
+
+// mediaType strips the parameters from a Content-Type header
+func mediaType(header string) string {
+	if i := strings.Index(header, ";"); i != -1 {
+		header = header[:i]
+	}
+	return strings.TrimSpace(strings.ToLower(header))
+}
+
+// Codec returns the codec a service uses for a Content-Type header.
+// The registered json codec is replaced by one following the json options of the service
+func (o *ServiceOptions) Codec(header string) (Codec, bool) {
+	c, ok := CodecForContentType(header)
+	if !ok {
+		return nil, false
+	}
+	if _, isJSON := c.(*JSONCodec); isJSON {
+		return &JSONCodec{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   !o.JSONCamelCase,
+				UseEnumNumbers:  o.JSONEnumsAsInts,
+				EmitUnpopulated: o.JSONEmitDefaults,
+				Indent:          o.JSONIndent,
+				AllowPartial:    o.JSONAllowPartial,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: !o.JSONRejectUnknownFields,
+				AllowPartial:   o.JSONAllowPartial,
+			},
+		}, true
+	}
+	return c, true
+}
+
+// ClientCodec returns the codec of a client. It defaults to ProtobufCodec,
+// a json codec is replaced by a copy following the json options of the client
+func (o *ClientOptions) ClientCodec() Codec {
+	switch c := o.Codec.(type) {
+	case nil:
+		return ProtobufCodec{}
+	case *JSONCodec:
+		j := *c
+		j.MarshalOptions.UseProtoNames = j.MarshalOptions.UseProtoNames && !o.JSONCamelCase
+		if o.JSONIndent != "" {
+			j.MarshalOptions.Indent = o.JSONIndent
+		}
+		j.MarshalOptions.AllowPartial = j.MarshalOptions.AllowPartial || o.JSONAllowPartial
+		j.UnmarshalOptions.DiscardUnknown = j.UnmarshalOptions.DiscardUnknown && !o.JSONRejectUnknownFields
+		j.UnmarshalOptions.AllowPartial = j.UnmarshalOptions.AllowPartial || o.JSONAllowPartial
+		return &j
+	default:
+		return c
+	}
+}
+
+// ResponseCodec negotiates the codec of the response from the Accept header of a request.
+// Entries are tried by descending quality, wildcards match the codec of the request.
+// Without an acceptable registered codec the codec of the request is used, which may be nil
+func (o *ServiceOptions) ResponseCodec(accept string, requestCodec Codec) Codec {
+	entries := parseQualityList(accept)
+	rejected := map[string]bool{}
+	for _, e := range entries {
+		if e.q <= 0 {
+			rejected[e.value] = true
+		}
+	}
+	for _, e := range entries {
+		if e.q <= 0 {
+			continue
+		}
+		switch {
+		case e.value == "*/*":
+			if acceptableCodec(requestCodec, "", rejected) {
+				return requestCodec
+			}
+		case strings.HasSuffix(e.value, "/*"):
+			if acceptableCodec(requestCodec, strings.TrimSuffix(e.value, "*"), rejected) {
+				return requestCodec
+			}
+		default:
+			if c, ok := o.Codec(e.value); ok {
+				return c
+			}
+		}
+	}
+	return requestCodec
+}
+
+// acceptableCodec reports whether a content type of the codec has the prefix and was not rejected with q=0
+func acceptableCodec(c Codec, prefix string, rejected map[string]bool) bool {
+	if c == nil {
+		return false
+	}
+	for _, ct := range c.ContentTypes() {
+		ct = strings.ToLower(ct)
+		if strings.HasPrefix(ct, prefix) && !rejected[ct] {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorCodecsHeader lists the names of the codecs a client decodes error bodies with, e.g. "protobuf".
+// Error bodies are encoded as JSON unless the client lists the negotiated response codec, as clients
+// without the header only decode JSON error bodies
+const ErrorCodecsHeader = "Blaze-Error-Codecs"
+
+// errorCodec returns the codec of the error bodies of the request of the context or nil for JSON
+func errorCodec(ctx context.Context) Codec {
+	c := ResponseCodecFromContext(ctx)
+	if c == nil {
+		return nil
+	}
+	for _, name := range strings.Split(RequestHeaders(ctx).Get(ErrorCodecsHeader), ",") {
+		if strings.TrimSpace(name) == c.Name() {
+			return c
+		}
+	}
+	return nil
+}
+
+type responseCodecKey struct{}
+
+// WithResponseCodec adds the negotiated response codec to the context
+func WithResponseCodec(ctx context.Context, c Codec) context.Context {
+	return context.WithValue(ctx, responseCodecKey{}, c)
+}
+
+// ResponseCodecFromContext returns the negotiated response codec of the context or nil
+func ResponseCodecFromContext(ctx context.Context) Codec {
+	c, _ := ctx.Value(responseCodecKey{}).(Codec)
+	return c
+}
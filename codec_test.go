@@ -0,0 +1,210 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+// textCodec encodes messages in the protobuf text format
+type textCodec struct{}
+
+func (textCodec) Name() string                                 { return "text" }
+func (textCodec) ContentTypes() []string                       { return []string{"text/x-protobuf"} }
+func (textCodec) Marshal(m proto.Message) ([]byte, error)      { return prototext.Marshal(m) }
+func (textCodec) Unmarshal(data []byte, m proto.Message) error { return prototext.Unmarshal(data, m) }
+
+// namedCodec is a protobuf codec with another name and content types
+type namedCodec struct {
+	blaze.ProtobufCodec
+	name         string
+	contentTypes []string
+}
+
+func (c namedCodec) Name() string           { return c.name }
+func (c namedCodec) ContentTypes() []string { return c.contentTypes }
+
+var _ = Describe("Codec registry", Ordered, func() {
+	BeforeAll(func() {
+		Expect(blaze.RegisterCodec(textCodec{})).To(Succeed())
+	})
+
+	It("finds registered codecs by name and content type", func() {
+		c, ok := blaze.CodecByName("text")
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(textCodec{}))
+		c, ok = blaze.CodecForContentType("Text/X-Protobuf; charset=utf-8")
+		Expect(ok).To(BeTrue())
+		Expect(c).To(Equal(textCodec{}))
+		_, ok = blaze.CodecByName("cbor")
+		Expect(ok).To(BeFalse())
+		_, ok = blaze.CodecForContentType("application/cbor")
+		Expect(ok).To(BeFalse())
+	})
+
+	DescribeTable("rejects duplicates",
+		func(c blaze.Codec, replaced string) {
+			Expect(blaze.RegisterCodec(c)).ToNot(Succeed())
+			By("keeping the registered codec")
+			registered, ok := blaze.CodecByName(replaced)
+			Expect(ok).To(BeTrue())
+			Expect(registered).ToNot(Equal(c))
+			_, ok = blaze.CodecForContentType("application/x-other")
+			Expect(ok).To(BeFalse())
+		},
+		Entry("names", namedCodec{name: "text", contentTypes: []string{"application/x-other"}}, "text"),
+		Entry("builtin names", namedCodec{name: blaze.CodecNameJSON, contentTypes: []string{"application/x-other"}}, blaze.CodecNameJSON),
+		Entry("content types", namedCodec{name: "other", contentTypes: []string{"application/x-other", "TEXT/X-PROTOBUF"}}, "text"),
+	)
+
+	It("serves generated services and clients", func() {
+		svc := health_v1.NewHealthService(servingHealth{}, logr.Discard())
+		mux := chi.NewMux()
+		mux.Mount(svc.MountPath(), svc.Mux())
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		client := &contentTypes{client: srv.Client()}
+		resp, err := health_v1.NewHealthClient(srv.URL, client, blaze.WithCodec(textCodec{})).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.GetStatus()).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+		Expect(client.types).To(Equal([]string{"text/x-protobuf"}))
+	})
+})
+
+var _ = Describe("ClientCodec", func() {
+	client := func(opts ...blaze.ClientOption) blaze.Codec {
+		o := blaze.ClientOptions{}
+		for _, opt := range opts {
+			opt(&o)
+		}
+		return o.ClientCodec()
+	}
+
+	It("defaults to protobuf", func() {
+		Expect(client()).To(Equal(blaze.ProtobufCodec{}))
+	})
+
+	It("uses the codec of the client", func() {
+		Expect(client(blaze.WithCodec(textCodec{}))).To(Equal(textCodec{}))
+	})
+
+	It("applies the json options of the client to a copy of the json codec", func() {
+		json := blaze.NewJSONCodec()
+		c, ok := client(
+			blaze.WithCodec(json),
+			blaze.WithClientJSONCamelCase(true),
+			blaze.WithClientJSONIndent("  "),
+			blaze.WithClientJSONAllowPartial(true),
+			blaze.WithClientJSONRejectUnknownFields(true),
+		).(*blaze.JSONCodec)
+		Expect(ok).To(BeTrue())
+		Expect(c).ToNot(BeIdenticalTo(json))
+		Expect(c.MarshalOptions.UseProtoNames).To(BeFalse())
+		Expect(c.MarshalOptions.Indent).To(Equal("  "))
+		Expect(c.MarshalOptions.AllowPartial).To(BeTrue())
+		Expect(c.UnmarshalOptions.DiscardUnknown).To(BeFalse())
+		Expect(c.UnmarshalOptions.AllowPartial).To(BeTrue())
+		By("leaving the given codec untouched")
+		Expect(json).To(Equal(blaze.NewJSONCodec()))
+	})
+
+	It("keeps the json codec without options", func() {
+		Expect(client(blaze.WithCodec(blaze.NewJSONCodec()))).To(Equal(blaze.NewJSONCodec()))
+	})
+})
+
+var _ = Describe("ResponseCodec", func() {
+	json := blaze.NewJSONCodec()
+	protobuf := blaze.ProtobufCodec{}
+
+	DescribeTable("negotiates the codec from the Accept header",
+		func(accept string, requestCodec blaze.Codec, expected string) {
+			o := blaze.ServiceOptions{}
+			c := o.ResponseCodec(accept, requestCodec)
+			if expected == "" {
+				Expect(c).To(BeNil())
+				return
+			}
+			Expect(c).ToNot(BeNil())
+			Expect(c.Name()).To(Equal(expected))
+		},
+		Entry("without Accept", "", protobuf, blaze.CodecNameProtobuf),
+		Entry("exact", "application/json", protobuf, blaze.CodecNameJSON),
+		Entry("case insensitive", "Application/JSON", protobuf, blaze.CodecNameJSON),
+		Entry("alias content type", "application/x-protobuf", json, blaze.CodecNameProtobuf),
+		Entry("with parameters", "application/json; charset=utf-8", protobuf, blaze.CodecNameJSON),
+		Entry("highest quality", "application/json;q=0.5, application/protobuf;q=0.9", json, blaze.CodecNameProtobuf),
+		Entry("default quality", "application/protobuf;q=0.5, application/json", protobuf, blaze.CodecNameJSON),
+		Entry("invalid quality as default", "application/protobuf;q=0.5, application/json;q=high", protobuf, blaze.CodecNameJSON),
+		Entry("ties in listed order", "application/json, application/protobuf", protobuf, blaze.CodecNameJSON),
+		Entry("weighted ties in listed order", "application/protobuf;q=0.8, application/json;q=0.8", json, blaze.CodecNameProtobuf),
+		Entry("q=0 excluded", "application/json;q=0, application/protobuf;q=0.1", json, blaze.CodecNameProtobuf),
+		Entry("unregistered skipped", "application/cbor, application/json;q=0.1", protobuf, blaze.CodecNameJSON),
+		Entry("wildcard as request codec", "*/*", json, blaze.CodecNameJSON),
+		Entry("wildcard before lower quality", "application/json;q=0.1, */*", protobuf, blaze.CodecNameProtobuf),
+		Entry("type wildcard matching request codec", "application/*", json, blaze.CodecNameJSON),
+		Entry("type wildcard not matching request codec", "text/*, application/json;q=0.1", protobuf, blaze.CodecNameJSON),
+		Entry("wildcard excluding rejected request codec", "application/json;q=0, */*;q=0.9, application/protobuf;q=0.1", json, blaze.CodecNameProtobuf),
+		Entry("type wildcard excluding rejected request codec", "application/json;q=0, application/*;q=0.9, application/protobuf;q=0.1", json, blaze.CodecNameProtobuf),
+		Entry("request codec without acceptable codec", "application/cbor", json, blaze.CodecNameJSON),
+		Entry("request codec if all are rejected", "application/json;q=0", json, blaze.CodecNameJSON),
+		Entry("no codec without request codec", "*/*", nil, ""),
+	)
+
+	It("applies the json options of the service", func() {
+		o := blaze.ServiceOptions{
+			JSONCamelCase:           true,
+			JSONEnumsAsInts:         true,
+			JSONEmitDefaults:        true,
+			JSONIndent:              "  ",
+			JSONAllowPartial:        true,
+			JSONRejectUnknownFields: true,
+		}
+		c, ok := o.ResponseCodec("application/json", protobuf).(*blaze.JSONCodec)
+		Expect(ok).To(BeTrue())
+		Expect(c.MarshalOptions.UseProtoNames).To(BeFalse())
+		Expect(c.MarshalOptions.UseEnumNumbers).To(BeTrue())
+		Expect(c.MarshalOptions.EmitUnpopulated).To(BeTrue())
+		Expect(c.MarshalOptions.Indent).To(Equal("  "))
+		Expect(c.MarshalOptions.AllowPartial).To(BeTrue())
+		Expect(c.UnmarshalOptions.DiscardUnknown).To(BeFalse())
+		Expect(c.UnmarshalOptions.AllowPartial).To(BeTrue())
+		By("leaving the registered codec untouched")
+		registered, _ := blaze.CodecByName(blaze.CodecNameJSON)
+		Expect(registered).To(Equal(blaze.NewJSONCodec()))
+	})
+
+	It("uses proto names and ignores unknown fields by default", func() {
+		o := blaze.ServiceOptions{}
+		c, ok := o.ResponseCodec("application/json", protobuf).(*blaze.JSONCodec)
+		Expect(ok).To(BeTrue())
+		Expect(c.MarshalOptions.UseProtoNames).To(BeTrue())
+		Expect(c.UnmarshalOptions.DiscardUnknown).To(BeTrue())
+	})
+
+	It("encodes responses of generated services with the json options", func() {
+		svc := health_v1.NewHealthService(servingHealth{}, logr.Discard(), blaze.WithJSONEnumsAsInts(true), blaze.WithJSONIndent("  "))
+		mux := chi.NewMux()
+		mux.Mount(svc.MountPath(), svc.Mux())
+		req := httptest.NewRequest(http.MethodPost, health_v1.HealthPathPrefix+"/Check", strings.NewReader(""))
+		req.Header.Set("Content-Type", "application/protobuf")
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(rec.Body.String()).To(MatchRegexp(`\{\n  "status":\s*1\n\}`))
+	})
+})
@@ -0,0 +1,221 @@
+package blaze
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// CompressionGzip is the gzip content encoding
+	CompressionGzip = "gzip"
+	// CompressionZstd is the zstd content encoding
+	CompressionZstd = "zstd"
+	// DefaultCompressionThreshold is the body size in bytes below which bodies are not compressed
+	DefaultCompressionThreshold = 1024
+	// DefaultMaxRequestBytes is the default limit of request bodies
+	DefaultMaxRequestBytes = 64 << 20
+)
+
+// acceptEncodings is sent by clients with compression to announce the supported encodings
+const acceptEncodings = CompressionZstd + ", " + CompressionGzip
+
+var (
+	gzipWriters sync.Pool
+	gzipReaders sync.Pool
+	zstdWriters sync.Pool
+	zstdReaders sync.Pool
+)
+
+// SupportedCompression returns true if the content encoding can be used by clients and servers
+func SupportedCompression(encoding string) bool {
+	return encoding == CompressionGzip || encoding == CompressionZstd
+}
+
+// compress encodes body with a pooled encoder
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch encoding {
+	case CompressionGzip:
+		w, ok := gzipWriters.Get().(*gzip.Writer)
+		if ok {
+			w.Reset(&buf)
+		} else {
+			w = gzip.NewWriter(&buf)
+		}
+		defer gzipWriters.Put(w)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		w, ok := zstdWriters.Get().(*zstd.Encoder)
+		if !ok {
+			var err error
+			if w, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); err != nil {
+				return nil, err
+			}
+		}
+		defer zstdWriters.Put(w)
+		return w.EncodeAll(body, make([]byte, 0, len(body)/2)), nil
+	default:
+		return nil, errors.New("unsupported content encoding " + strconv.Quote(encoding))
+	}
+}
+
+var errDecompressedTooLarge = errors.New("decompressed body exceeds the limit")
+
+// readLimited reads r completely, failing if it yields more than limit bytes. A negative limit disables the check
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return io.ReadAll(r)
+	}
+	buf, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(buf)) > limit {
+		return nil, errDecompressedTooLarge
+	}
+	return buf, nil
+}
+
+// decompress decodes the whole stream with a pooled decoder, yielding at most limit bytes
+func decompress(encoding string, r io.Reader, limit int64) ([]byte, error) {
+	switch encoding {
+	case "", "identity":
+		return io.ReadAll(r)
+	case CompressionGzip:
+		zr, ok := gzipReaders.Get().(*gzip.Reader)
+		var err error
+		if ok {
+			err = zr.Reset(r)
+		} else {
+			zr, err = gzip.NewReader(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReaders.Put(zr)
+		return readLimited(zr, limit)
+	case CompressionZstd:
+		zr, ok := zstdReaders.Get().(*zstd.Decoder)
+		var err error
+		if ok {
+			err = zr.Reset(r)
+		} else {
+			zr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = zr.Reset(nil)
+			zstdReaders.Put(zr)
+		}()
+		return readLimited(zr, limit)
+	default:
+		return nil, errors.New("unsupported content encoding " + strconv.Quote(encoding))
+	}
+}
+
+func contentEncoding(h http.Header) string {
+	return strings.ToLower(strings.TrimSpace(h.Get("Content-Encoding")))
+}
+
+// CompressRequest compresses the body of a client request if the client is configured WithCompression
+// and the body reaches the compression threshold. GET requests are not compressed. It announces the supported encodings for the response
+func CompressRequest(req *http.Request, body []byte, opts ClientOptions) error {
+	if opts.Compression == "" {
+		return nil
+	}
+	req.Header.Set("Accept-Encoding", acceptEncodings)
+	if req.Method == http.MethodGet {
+		return nil
+	}
+	threshold := opts.CompressionThreshold
+	if threshold == 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	if len(body) < threshold {
+		return nil
+	}
+	buf, err := compress(opts.Compression, body)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(buf))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(buf)), nil }
+	req.ContentLength = int64(len(buf))
+	req.Header.Set("Content-Encoding", opts.Compression)
+	return nil
+}
+
+// ReadResponseBody reads the body of a response to a client, decoding its content encoding
+func ReadResponseBody(resp *http.Response) ([]byte, error) {
+	return decompress(contentEncoding(resp.Header), resp.Body, -1)
+}
+
+// ReadRequestBody reads the body of a request to a service, decoding its content encoding.
+// Bodies exceeding limit before or after decoding are rejected, a limit of zero uses DefaultMaxRequestBytes
+// and a negative limit disables it. It returns blaze errors to be written with ServerWriteError
+func ReadRequestBody(resp http.ResponseWriter, req *http.Request, limit int64) ([]byte, error) {
+	encoding := contentEncoding(req.Header)
+	if encoding != "" && encoding != "identity" && !SupportedCompression(encoding) {
+		msg := "unsupported Content-Encoding: " + strconv.Quote(encoding)
+		return nil, ServerInvalidRequestError("Content-Encoding", msg, req.Method, req.URL.Path)
+	}
+	if limit == 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	var body io.Reader = req.Body
+	if limit > 0 {
+		body = http.MaxBytesReader(resp, req.Body, limit)
+	}
+	buf, err := decompress(encoding, body, limit)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, errDecompressedTooLarge) {
+		return nil, ErrorRequestTooLarge(limit)
+	}
+	if err != nil {
+		if encoding != "" && encoding != "identity" {
+			return nil, ErrorMalformed("the request body could not be decompressed")
+		}
+		return nil, ErrorInternalWith(err, "failed to read request body")
+	}
+	return buf, nil
+}
+
+// CompressResponseBody compresses the response body of a service with the preferred encoding of
+// the Accept-Encoding header of the request if the body reaches the threshold of the service.
+// It returns the body and the content encoding, which is empty if the body is not compressed
+func CompressResponseBody(req *http.Request, body []byte, opts ServiceOptions) ([]byte, string, error) {
+	threshold := opts.CompressionThreshold
+	if threshold == 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	if threshold < 0 || len(body) < threshold {
+		return body, "", nil
+	}
+	for _, e := range parseQualityList(req.Header.Get("Accept-Encoding")) {
+		if e.q <= 0 || !SupportedCompression(e.value) {
+			continue
+		}
+		buf, err := compress(e.value, body)
+		if err != nil {
+			return nil, "", err
+		}
+		return buf, e.value, nil
+	}
+	return body, "", nil
+}
@@ -0,0 +1,152 @@
+package blaze_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+var _ = Describe("Compression", func() {
+	large := []byte(strings.Repeat("compressible ", 1024))
+
+	// compressedRequest compresses body like a client WithCompression
+	compressedRequest := func(encoding string, body []byte) *http.Request {
+		opts := blaze.ClientOptions{}
+		blaze.WithCompression(encoding)(&opts)
+		req := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(body))
+		Expect(blaze.CompressRequest(req, body, opts)).To(Succeed())
+		return req
+	}
+
+	DescribeTable("validates the encoding of clients",
+		func(encoding string, valid bool) {
+			opts := blaze.ClientOptions{}
+			blaze.WithCompression(encoding)(&opts)
+			if valid {
+				Expect(opts.Validate()).To(Succeed())
+			} else {
+				Expect(opts.Validate()).To(MatchError(ContainSubstring(strconv.Quote(encoding))))
+			}
+		},
+		Entry("disabled", "", true),
+		Entry("gzip", blaze.CompressionGzip, true),
+		Entry("zstd", blaze.CompressionZstd, true),
+		Entry("unsupported", "br", false),
+	)
+
+	It("fails the calls of generated clients with an unsupported encoding", func() {
+		client := &contentTypes{client: http.DefaultClient}
+		health := health_v1.NewHealthClient("http://localhost:1", client, blaze.WithCompression("br"))
+		for i := 0; i < 2; i++ {
+			_, err := health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			Expect(err).To(HaveOccurred())
+			Expect(errors.As(err, new(*blaze.InternalErrorType))).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(`"br"`))
+		}
+		Expect(client.types).To(BeEmpty())
+	})
+
+	DescribeTable("round trips requests and responses",
+		func(encoding string) {
+			req := compressedRequest(encoding, large)
+			Expect(req.Header.Get("Content-Encoding")).To(Equal(encoding))
+			Expect(req.Header.Get("Accept-Encoding")).To(ContainSubstring(encoding))
+			Expect(req.ContentLength).To(BeNumerically("<", len(large)))
+			buf, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf).To(Equal(large))
+
+			req.Header.Set("Accept-Encoding", encoding)
+			respBody, respEncoding, err := blaze.CompressResponseBody(req, large, blaze.ServiceOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(respEncoding).To(Equal(encoding))
+			resp := &http.Response{Header: http.Header{"Content-Encoding": {respEncoding}}, Body: io.NopCloser(bytes.NewReader(respBody))}
+			buf, err = blaze.ReadResponseBody(resp)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf).To(Equal(large))
+		},
+		Entry("gzip", blaze.CompressionGzip),
+		Entry("zstd", blaze.CompressionZstd),
+	)
+
+	It("does not compress small bodies and GET requests", func() {
+		req := compressedRequest(blaze.CompressionGzip, []byte("small"))
+		Expect(req.Header.Get("Content-Encoding")).To(BeEmpty())
+
+		opts := blaze.ClientOptions{}
+		blaze.WithCompression(blaze.CompressionGzip)(&opts)
+		get := httptest.NewRequest(http.MethodGet, "/svc/Method", nil)
+		Expect(blaze.CompressRequest(get, large, opts)).To(Succeed())
+		Expect(get.Header.Get("Content-Encoding")).To(BeEmpty())
+		Expect(get.Header.Get("Accept-Encoding")).ToNot(BeEmpty())
+	})
+
+	It("prefers the encoding of the highest quality for responses", func() {
+		req := httptest.NewRequest(http.MethodPost, "/svc/Method", nil)
+		req.Header.Set("Accept-Encoding", "br;q=1, gzip;q=0.5, zstd;q=0.8")
+		_, encoding, err := blaze.CompressResponseBody(req, large, blaze.ServiceOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encoding).To(Equal(blaze.CompressionZstd))
+
+		req.Header.Set("Accept-Encoding", "gzip;q=0")
+		body, encoding, err := blaze.CompressResponseBody(req, large, blaze.ServiceOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encoding).To(BeEmpty())
+		Expect(body).To(Equal(large))
+	})
+
+	DescribeTable("limits the decompressed size of requests",
+		func(encoding string) {
+			bomb := make([]byte, 1<<20)
+			req := compressedRequest(encoding, bomb)
+			Expect(req.ContentLength).To(BeNumerically("<", 64<<10))
+			_, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, 64<<10)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(blaze.Error).MetaMap()).To(HaveKeyWithValue(blaze.MaxRequestBytesMetaKey, "65536"))
+		},
+		Entry("gzip", blaze.CompressionGzip),
+		Entry("zstd", blaze.CompressionZstd),
+	)
+
+	It("limits the compressed size of requests", func() {
+		req := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(large))
+		_, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, 1024)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).MetaMap()).To(HaveKeyWithValue(blaze.MaxRequestBytesMetaKey, "1024"))
+	})
+
+	It("reads bodies up to the limit", func() {
+		req := compressedRequest(blaze.CompressionGzip, large)
+		buf, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, int64(len(large)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf).To(Equal(large))
+	})
+
+	It("rejects unsupported content encodings", func() {
+		req := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(large))
+		req.Header.Set("Content-Encoding", "br")
+		_, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, 0)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorInvalidArgument("", "").Type()))
+	})
+
+	It("rejects corrupt bodies", func() {
+		req := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(large))
+		req.Header.Set("Content-Encoding", blaze.CompressionGzip)
+		_, err := blaze.ReadRequestBody(httptest.NewRecorder(), req, 0)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorMalformed("").Type()))
+	})
+})
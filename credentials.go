@@ -0,0 +1,38 @@
+package blaze
+
+import (
+	"context"
+	"net/http"
+)
+
+// Credentials provide the headers authenticating the calls of a client, e.g. a bearer token
+type Credentials interface {
+	// Headers returns the headers to attach to the request of a call
+	Headers(ctx context.Context) (http.Header, error)
+}
+
+// WithCredentials attaches the headers of the credentials to every call of the client
+func WithCredentials(credentials Credentials) ClientOption {
+	return func(o *ClientOptions) {
+		o.Credentials = credentials
+	}
+}
+
+// AttachCredentials sets the headers of the credentials of the client on the request of a call.
+// Failures to obtain the credentials are returned as unauthenticated errors
+func AttachCredentials(ctx context.Context, req *http.Request, opts ClientOptions) error {
+	if opts.Credentials == nil {
+		return nil
+	}
+	h, err := opts.Credentials.Headers(ctx)
+	if err != nil {
+		if blerr, ok := err.(Error); ok {
+			return blerr
+		}
+		return ErrorUnauthenticated("failed to obtain credentials: " + err.Error())
+	}
+	for k, v := range h {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package blaze
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader is the header carrying the time in milliseconds a client is willing to wait for a response
+const TimeoutHeader = "Blaze-Timeout"
+
+// encodeTimeout renders the time until the deadline in milliseconds, rounded up so a pending deadline never becomes 0
+func encodeTimeout(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "0"
+	}
+	ms := (timeout + time.Millisecond - 1) / time.Millisecond
+	return strconv.FormatInt(int64(ms), 10)
+}
+
+func decodeTimeout(v string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, errors.New("must be a non negative number of milliseconds")
+	}
+	if ms > int64(time.Duration(1<<63-1)/time.Millisecond) {
+		return 0, errors.New("is out of range")
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// ServerContextWithTimeout derives the context a request is served with from the Blaze-Timeout header of the request.
+// A maxTimeout greater than zero caps the timeout and is applied to requests without the header.
+// An error is returned if the header is invalid or the deadline is already exceeded
+func ServerContextWithTimeout(ctx context.Context, req *http.Request, maxTimeout time.Duration) (context.Context, context.CancelFunc, error) {
+	timeout := maxTimeout
+	if v := req.Header.Get(TimeoutHeader); v != "" {
+		t, err := decodeTimeout(v)
+		if err != nil {
+			return ctx, func() {}, ServerInvalidRequestError(TimeoutHeader, TimeoutHeader+" "+err.Error(), req.Method, req.URL.Path)
+		}
+		if maxTimeout <= 0 || t < maxTimeout {
+			timeout = t
+		}
+	} else if maxTimeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, ErrorDeadlineExceeded("the deadline of the request was exceeded before it was served")
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// ServerDeadlineError reports the outcome of a request whose deadline expired as exceeded deadline,
+// whether the handler returned a response or another error, as the client no longer waits for either.
+// The outcome of requests within their deadline is returned as is
+func ServerDeadlineError(ctx context.Context, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	var exceeded *DeadlineExceededErrorType
+	if errors.As(err, &exceeded) {
+		return err
+	}
+	return ErrorDeadlineExceededWith(err, "the deadline of the request was exceeded")
+}
@@ -0,0 +1,201 @@
+package blaze_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+// slowHealth answers after the delay with err or a serving response
+type slowHealth struct {
+	delay time.Duration
+	err   error
+}
+
+func (h slowHealth) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	time.Sleep(h.delay)
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+var _ = Describe("Deadlines", func() {
+	DescribeTable("encode the timeout in milliseconds rounded up",
+		func(timeout time.Duration, encoded string) {
+			Expect(blaze.EncodeTimeout(timeout)).To(Equal(encoded))
+		},
+		Entry("expired", -time.Second, "0"),
+		Entry("zero", time.Duration(0), "0"),
+		Entry("below a millisecond", time.Nanosecond, "1"),
+		Entry("milliseconds", 250*time.Millisecond, "250"),
+		Entry("fractions of milliseconds", 1500*time.Microsecond, "2"),
+		Entry("seconds", 3*time.Second, "3000"),
+	)
+
+	DescribeTable("decode the timeout",
+		func(encoded string, timeout time.Duration) {
+			Expect(blaze.DecodeTimeout(encoded)).To(Equal(timeout))
+		},
+		Entry("zero", "0", time.Duration(0)),
+		Entry("milliseconds", "250", 250*time.Millisecond),
+		Entry("largest duration", "9223372036854", 9223372036854*time.Millisecond),
+	)
+
+	DescribeTable("reject invalid timeouts",
+		func(encoded string) {
+			_, err := blaze.DecodeTimeout(encoded)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("negative", "-1"),
+		Entry("unit", "10ms"),
+		Entry("fraction", "1.5"),
+		Entry("overflowing a duration", "9223372036855"),
+		Entry("overflowing an int64", "9223372036854775808"),
+	)
+
+	Describe("ServerContextWithTimeout", func() {
+		request := func(timeout string) *http.Request {
+			req := httptest.NewRequest("POST", "/svc/Method", nil)
+			if timeout != "" {
+				req.Header.Set(blaze.TimeoutHeader, timeout)
+			}
+			return req
+		}
+		deadline := func(timeout string, maxTimeout time.Duration) (time.Duration, bool) {
+			ctx, cancel, err := blaze.ServerContextWithTimeout(context.Background(), request(timeout), maxTimeout)
+			defer cancel()
+			Expect(err).ToNot(HaveOccurred())
+			d, ok := ctx.Deadline()
+			return time.Until(d), ok
+		}
+
+		It("leaves requests without timeout unbounded", func() {
+			_, ok := deadline("", 0)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("applies the timeout of the request", func() {
+			d, ok := deadline("1000", 0)
+			Expect(ok).To(BeTrue())
+			Expect(d).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		})
+
+		It("caps the timeout of the request", func() {
+			d, ok := deadline("60000", time.Second)
+			Expect(ok).To(BeTrue())
+			Expect(d).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		})
+
+		It("keeps shorter timeouts of the request", func() {
+			d, ok := deadline("100", time.Second)
+			Expect(ok).To(BeTrue())
+			Expect(d).To(BeNumerically("~", 100*time.Millisecond, 50*time.Millisecond))
+		})
+
+		It("applies the maximum to requests without timeout", func() {
+			d, ok := deadline("", time.Second)
+			Expect(ok).To(BeTrue())
+			Expect(d).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		})
+
+		It("rejects requests whose deadline is exceeded", func() {
+			_, cancel, err := blaze.ServerContextWithTimeout(context.Background(), request("0"), 0)
+			defer cancel()
+			Expect(err).To(HaveOccurred())
+			Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorDeadlineExceeded("").Type()))
+		})
+
+		DescribeTable("rejects invalid timeouts",
+			func(timeout string) {
+				_, cancel, err := blaze.ServerContextWithTimeout(context.Background(), request(timeout), time.Second)
+				defer cancel()
+				Expect(err).To(HaveOccurred())
+				blerr := err.(blaze.Error)
+				Expect(blerr.Type()).To(Equal(blaze.ErrorInvalidArgument("", "").Type()))
+				Expect(blerr.Meta("argument")).To(Equal(blaze.TimeoutHeader))
+			},
+			Entry("negative", "-5"),
+			Entry("unit", "5s"),
+			Entry("overflowing", "9223372036855"),
+		)
+	})
+
+	Describe("ServerDeadlineError", func() {
+		expired := func() context.Context {
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			DeferCleanup(cancel)
+			return ctx
+		}
+
+		It("keeps the outcome of requests within their deadline", func() {
+			Expect(blaze.ServerDeadlineError(context.Background(), nil)).To(Succeed())
+			err := blaze.ErrorNotFound("missing")
+			Expect(blaze.ServerDeadlineError(context.Background(), err)).To(BeIdenticalTo(err))
+		})
+
+		DescribeTable("reports the outcome of expired requests as exceeded deadline",
+			func(err error) {
+				blerr, ok := blaze.ServerDeadlineError(expired(), err).(blaze.Error)
+				Expect(ok).To(BeTrue())
+				Expect(blerr.Type()).To(Equal(blaze.ErrorDeadlineExceeded("").Type()))
+				if err != nil {
+					Expect(errors.Is(blerr, err)).To(BeTrue())
+				}
+			},
+			Entry("response", nil),
+			Entry("blaze error", blaze.ErrorNotFound("missing")),
+			Entry("error", errors.New("failed")),
+			Entry("exceeded deadline", blaze.ErrorDeadlineExceeded("too slow")),
+		)
+	})
+
+	Context("of generated services", func() {
+		serve := func(h slowHealth) *http.Response {
+			svc := health_v1.NewHealthService(h, logr.Discard())
+			mux := chi.NewMux()
+			mux.Mount(svc.MountPath(), svc.Mux())
+			srv := httptest.NewServer(mux)
+			DeferCleanup(srv.Close)
+			body, err := proto.Marshal(&grpc_health_v1.HealthCheckRequest{})
+			Expect(err).ToNot(HaveOccurred())
+			req, err := http.NewRequest(http.MethodPost, srv.URL+health_v1.HealthPathPrefix+"/Check", bytes.NewReader(body))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Content-Type", "application/protobuf")
+			req.Header.Set(blaze.TimeoutHeader, "10")
+			resp, err := srv.Client().Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		It("serve handlers finishing within the deadline", func() {
+			Expect(serve(slowHealth{}).StatusCode).To(Equal(http.StatusOK))
+		})
+
+		DescribeTable("report handlers finishing after the deadline as exceeded deadline",
+			func(err error) {
+				resp := serve(slowHealth{delay: 50 * time.Millisecond, err: err})
+				Expect(resp.StatusCode).To(Equal(http.StatusRequestTimeout))
+				Expect(blaze.ErrorFromResponse(resp).Type()).To(Equal(blaze.ErrorDeadlineExceeded("").Type()))
+			},
+			Entry("response", nil),
+			Entry("blaze error", blaze.ErrorNotFound("missing")),
+			Entry("error", errors.New("failed")),
+		)
+	})
+})
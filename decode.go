@@ -0,0 +1,161 @@
+package blaze
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// FieldMetaKey is the meta key naming the path of the offending field of a malformed request e.g. items[2].name
+	FieldMetaKey = "field"
+	// PositionMetaKey is the meta key stating the line:column of a malformed json request
+	PositionMetaKey = "position"
+)
+
+var (
+	unknownFieldPattern = regexp.MustCompile(`unknown field "([^"]+)"`)
+	positionPattern     = regexp.MustCompile(`\(line (\d+):(\d+)\):?\s*(.*)$`)
+)
+
+// ServerDecodeError creates the malformed error of a request body the codec could not decode.
+// Errors of json codecs name the field path and position of the failure in the message and the meta.
+// Json requests may use the proto names as well as the lowerCamelCase names of fields
+func ServerDecodeError(codec Codec, err error, body []byte) Error {
+	msg := "the " + codec.Name() + " request could not be decoded"
+	m := positionPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		if u := unknownFieldPattern.FindStringSubmatch(err.Error()); u != nil {
+			return ErrorMalformed(msg+": unknown field "+u[1]).WithMeta(FieldMetaKey, u[1])
+		}
+		return ErrorMalformed(msg)
+	}
+	line, _ := strconv.Atoi(m[1])
+	column, _ := strconv.Atoi(m[2])
+	position := m[1] + ":" + m[2]
+	path := jsonPathAt(body, jsonOffset(body, line, column))
+	if u := unknownFieldPattern.FindStringSubmatch(m[3]); u != nil && path == "" {
+		path = u[1]
+	}
+	blerr := ErrorMalformed(msg + " at " + describePath(path) + "position " + position + ": " + m[3])
+	blerr = blerr.WithMeta(PositionMetaKey, position)
+	if path != "" {
+		blerr = blerr.WithMeta(FieldMetaKey, path)
+	}
+	return blerr
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return "field " + path + ", "
+}
+
+// jsonOffset converts a 1-based line and rune column into a byte offset of data
+func jsonOffset(data []byte, line, column int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(data[offset:], '\n')
+		if i == -1 {
+			return len(data)
+		}
+		offset += i + 1
+	}
+	for c := 1; c < column && offset < len(data); c++ {
+		_, size := utf8.DecodeRune(data[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// jsonPathFrame is an object or array being scanned by jsonPathAt
+type jsonPathFrame struct {
+	array      bool
+	index      int
+	key        string
+	expectsKey bool
+}
+
+// jsonPathAt returns the path of the json value or key at the byte offset e.g. items[2].name.
+// Scanning stops at the offset so the data may be malformed after it
+func jsonPathAt(data []byte, offset int) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var stack []*jsonPathFrame
+	value := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if top := stack[len(stack)-1]; top.array {
+			top.index++
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		var top *jsonPathFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				value()
+				if dec.InputOffset() > int64(offset) {
+					return renderJSONPath(stack)
+				}
+				stack = append(stack, &jsonPathFrame{array: t == '[', index: -1, expectsKey: t == '{'})
+				continue
+			default:
+				if dec.InputOffset() > int64(offset) {
+					return renderJSONPath(stack)
+				}
+				stack = stack[:len(stack)-1]
+				if len(stack) > 0 {
+					stack[len(stack)-1].expectsKey = !stack[len(stack)-1].array
+				}
+				continue
+			}
+		case string:
+			if top != nil && !top.array && top.expectsKey {
+				top.key = t
+				top.expectsKey = false
+				if dec.InputOffset() > int64(offset) {
+					return renderJSONPath(stack)
+				}
+				continue
+			}
+		}
+		value()
+		if dec.InputOffset() > int64(offset) {
+			return renderJSONPath(stack)
+		}
+		if top != nil && !top.array {
+			top.expectsKey = true
+		}
+	}
+	return renderJSONPath(stack)
+}
+
+func renderJSONPath(stack []*jsonPathFrame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		switch {
+		case f.array && f.index >= 0:
+			b.WriteString("[" + strconv.Itoa(f.index) + "]")
+		case !f.array && f.key != "":
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(f.key)
+		}
+	}
+	return b.String()
+}
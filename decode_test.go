@@ -0,0 +1,92 @@
+package blaze_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"code.cestus.io/blaze"
+)
+
+var _ = Describe("ServerDecodeError", func() {
+	codec := blaze.NewJSONCodec()
+	codec.UnmarshalOptions.DiscardUnknown = false
+
+	decode := func(body string) blaze.Error {
+		err := codec.Unmarshal([]byte(body), &descriptorpb.FileDescriptorProto{})
+		Expect(err).To(HaveOccurred())
+		return blaze.ServerDecodeError(codec, err, []byte(body))
+	}
+
+	// the decode errors are parsed from the protojson messages, these pin the parts the parsing relies on
+	DescribeTable("protojson error messages",
+		func(body string, pattern string) {
+			err := protojson.Unmarshal([]byte(body), &descriptorpb.FileDescriptorProto{})
+			Expect(err).To(MatchError(MatchRegexp(pattern)))
+		},
+		Entry("invalid value", `{"name": 5}`, `\(line 1:10\): invalid value for string type: 5$`),
+		Entry("unknown field", `{"nope": 1}`, `\(line 1:2\): unknown field "nope"$`),
+		Entry("syntax error", `[1]`, `syntax error \(line 1:1\): unexpected token \[$`),
+		Entry("truncated", `{"name": "a",`, `unexpected EOF$`),
+	)
+
+	DescribeTable("reports field and position",
+		func(body, field, position, msg string) {
+			blerr := decode(body)
+			Expect(blerr.Type()).To(Equal(blaze.ErrorMalformed("").Type()))
+			Expect(blerr.Msg()).To(Equal(msg))
+			meta := blerr.MetaMap()
+			if field == "" {
+				Expect(meta).ToNot(HaveKey(blaze.FieldMetaKey))
+			} else {
+				Expect(meta).To(HaveKeyWithValue(blaze.FieldMetaKey, field))
+			}
+			if position == "" {
+				Expect(meta).ToNot(HaveKey(blaze.PositionMetaKey))
+			} else {
+				Expect(meta).To(HaveKeyWithValue(blaze.PositionMetaKey, position))
+			}
+		},
+		Entry("top level field", `{"name": 5}`, "name", "1:10",
+			"the json request could not be decoded at field name, position 1:10: invalid value for string type: 5"),
+		Entry("nested arrays", `{"message_type": [{"name": "a"}, {"field": [{"name": "x"}, {"number": "abc"}]}]}`,
+			"message_type[1].field[1].number", "1:71",
+			`the json request could not be decoded at field message_type[1].field[1].number, position 1:71: invalid value for int32 type: "abc"`),
+		Entry("lowerCamelCase names", `{"messageType": [{"name": "a"}, {"name": true}]}`, "messageType[1].name", "1:42",
+			"the json request could not be decoded at field messageType[1].name, position 1:42: invalid value for string type: true"),
+		Entry("unknown field", `{"message_type": [{"nope": 1}]}`, "message_type[0].nope", "1:20",
+			`the json request could not be decoded at field message_type[0].nope, position 1:20: unknown field "nope"`),
+		Entry("later line", "{\"name\": \"a\"\n,\n \"package\": 3}", "package", "3:13",
+			"the json request could not be decoded at field package, position 3:13: invalid value for string type: 3"),
+		Entry("syntax error", `[1]`, "", "1:1",
+			"the json request could not be decoded at position 1:1: unexpected token ["),
+		Entry("truncated", `{"name": "a",`, "", "", "the json request could not be decoded"),
+	)
+
+	It("does not describe errors of other codecs", func() {
+		blerr := blaze.ServerDecodeError(blaze.ProtobufCodec{}, errors.New("proto: cannot parse invalid wire-format data"), []byte{0xff})
+		Expect(blerr.Msg()).To(Equal("the protobuf request could not be decoded"))
+		Expect(blerr.MetaMap()).To(BeEmpty())
+	})
+})
+
+var _ = DescribeTable("json path at a position",
+	func(data string, line, column int, path string) {
+		Expect(blaze.JSONPathAt(data, line, column)).To(Equal(path))
+	},
+	Entry("start of the document", `{"a": 1}`, 1, 1, ""),
+	Entry("key", `{"a": 1}`, 1, 2, "a"),
+	Entry("value", `{"a": 1}`, 1, 7, "a"),
+	Entry("nested object", `{"a": {"b": {"c": true}}}`, 1, 19, "a.b.c"),
+	Entry("array element", `{"a": [1, 2, 3]}`, 1, 14, "a[2]"),
+	Entry("object in an array", `{"a": [{"b": 1}, {"b": 2, "c": 3}]}`, 1, 27, "a[1].c"),
+	Entry("nested arrays", `{"a": [[1], [2, 3]]}`, 1, 17, "a[1][1]"),
+	Entry("top level array", `[{"a": 1}, {"b": 2}]`, 1, 17, "[1].b"),
+	Entry("after a closed object", `{"a": {"b": 1}, "c": 2}`, 1, 22, "c"),
+	Entry("later line", "{\n  \"a\": [\n    1,\n    \"x\"\n  ]\n}", 4, 5, "a[1]"),
+	Entry("multi-byte runes", `{"ä": "öü", "b": 1}`, 1, 18, "b"),
+	Entry("malformed after the position", `{"a": [1, 2}`, 1, 11, "a[1]"),
+)
@@ -31,6 +31,7 @@ package blaze
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	otelc "go.opentelemetry.io/otel/codes"
 	"google.golang.org/grpc/codes"
@@ -147,6 +148,7 @@ func IsError(err error) bool {
 // response status. It is used by the blaze server handler to set the HTTP
 // response status code. Returns 0 if the error is not a blaze error.
 func ServerHTTPStatusFromErrorType(err error) int {
+	orig := err
 	switch err.(type) {
 	case Error:
 		{
@@ -175,6 +177,9 @@ func ServerHTTPStatusFromErrorType(err error) int {
 	case *UnauthenticatedErrorType:
 		return 401 // Unauthorized
 	case *ResourceExhaustedErrorType:
+		if blerr, ok := orig.(Error); ok && blerr.Meta(MaxRequestBytesMetaKey) != "" {
+			return 413 // Request Entity Too Large
+		}
 		return 429 // RessourceExhausted
 	case *FailedPreconditionErrorType:
 		return 412 // Precondition Failed
@@ -203,6 +208,17 @@ func OtelCodeFromErrorType(err error) otelc.Code {
 	return otelc.Error
 }
 
+// OtelServerCodeFromErrorType converts to the open telemetry code of a server span. Following the rpc semantic
+// conventions only errors which indicate a fault of the server mark the span as failed
+func OtelServerCodeFromErrorType(err error) otelc.Code {
+	switch GrpcCodeFromErrorType(err) {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return otelc.Error
+	default:
+		return otelc.Unset
+	}
+}
+
 // GrpcCodeFromErrorType converts a blaze error into a grpc error code
 func GrpcCodeFromErrorType(err error) codes.Code {
 	if err == nil {
@@ -257,13 +273,23 @@ func GrpcCodeFromErrorType(err error) codes.Code {
 }
 
 //CanceledErrorType indicates the operation was cancelled (typically by the caller).
-type CanceledErrorType struct{}
+type CanceledErrorType struct {
+	err error
+}
 
 func (e *CanceledErrorType) Error() string { return "canceled" }
 
+// Unwrap implements the wrappable error
+func (e *CanceledErrorType) Unwrap() error { return e.err }
+
 //ErrorCanceled constructs a canceled error
 func ErrorCanceled(msg string) Error { return NewError(&CanceledErrorType{}, msg) }
 
+//ErrorCanceledWith constructs a canceled error wrapping the cause
+func ErrorCanceledWith(err error, msg string) Error {
+	return NewError(&CanceledErrorType{err: err}, msg)
+}
+
 //NotFoundErrorType indicates a common NotFound error
 type NotFoundErrorType struct{}
 
@@ -347,12 +373,27 @@ func ErrorMalformed(msg string) Error { return NewError(&MalformedErrorType{}, m
 //DeadlineExceededErrorType means operation expired before completion. For operations
 // that change the state of the system, this error may be returned even if the
 // operation has completed successfully (timeout).
-type DeadlineExceededErrorType struct{}
+type DeadlineExceededErrorType struct {
+	err error
+}
+
+func (e *DeadlineExceededErrorType) Error() string { return "deadline_exceeded" }
 
-func (e *DeadlineExceededErrorType) Error() string { return "deadline_exeeded" }
+// Unwrap implements the wrappable error
+func (e *DeadlineExceededErrorType) Unwrap() error { return e.err }
+
+//ErrorDeadlineExceeded constructs a deadline exceeded error
+func ErrorDeadlineExceeded(msg string) Error { return NewError(&DeadlineExceededErrorType{}, msg) }
 
-//ErrorDeadlineExeeded constructs a canceled error
-func ErrorDeadlineExeeded(msg string) Error { return NewError(&DeadlineExceededErrorType{}, msg) }
+//ErrorDeadlineExeeded constructs a deadline exceeded error
+//
+// Deprecated: use ErrorDeadlineExceeded
+func ErrorDeadlineExeeded(msg string) Error { return ErrorDeadlineExceeded(msg) }
+
+//ErrorDeadlineExceededWith constructs a deadline exceeded error wrapping the cause
+func ErrorDeadlineExceededWith(err error, msg string) Error {
+	return NewError(&DeadlineExceededErrorType{err: err}, msg)
+}
 
 // BadRouteErrorType means that the requested URL path wasn't routable to a blaze
 // service and method. This is returned by the generated server, and usually
@@ -402,6 +443,16 @@ func (e *ResourceExhaustedErrorType) Error() string { return "resource_exhausted
 //ErrorResourceExhausted constructs a resource exhousted error
 func ErrorResourceExhausted(msg string) Error { return NewError(&ResourceExhaustedErrorType{}, msg) }
 
+// MaxRequestBytesMetaKey is the meta key stating the limit of a rejected request body
+const MaxRequestBytesMetaKey = "max_request_bytes"
+
+// ErrorRequestTooLarge constructs a resource exhausted error for a request body exceeding limit.
+// It is served with status 413 instead of 429
+func ErrorRequestTooLarge(limit int64) Error {
+	msg := fmt.Sprintf("the request body exceeds the limit of %d bytes", limit)
+	return ErrorResourceExhausted(msg).WithMeta(MaxRequestBytesMetaKey, strconv.FormatInt(limit, 10))
+}
+
 // FailedPreconditionErrorType indicates operation was rejected because the system is
 // not in a state required for the operation's execution. For example, doing
 // an rmdir operation on a directory that is non-empty, or on a non-directory
@@ -451,13 +502,23 @@ func ErrorUnimplemented(msg string) Error { return NewError(&UnimplementedErrorT
 // UnavailableErrorType indicates the service is currently unavailable. This is a most
 // likely a transient condition and may be corrected by retrying with a
 // backoff.
-type UnavailableErrorType struct{}
+type UnavailableErrorType struct {
+	err error
+}
 
 func (e *UnavailableErrorType) Error() string { return "unavailable" }
 
+// Unwrap implements the wrappable error
+func (e *UnavailableErrorType) Unwrap() error { return e.err }
+
 //ErrorUnavailable constructs an unavailable error
 func ErrorUnavailable(msg string) Error { return NewError(&UnavailableErrorType{}, msg) }
 
+//ErrorUnavailableWith constructs an unavailable error wrapping the cause
+func ErrorUnavailableWith(err error, msg string) Error {
+	return NewError(&UnavailableErrorType{err: err}, msg)
+}
+
 // DataLossErrorType indicates unrecoverable data loss or corruption.
 type DataLossErrorType struct{}
 
@@ -1,14 +1,28 @@
 package blaze_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	otelc "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/descriptorpb"
 
 	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
 	//. "code.cestus.io/blaze"
 )
 
@@ -86,7 +100,7 @@ var _ = Describe("Error", func() {
 			Entry("MalformedErrorType",
 				blaze.ErrorMalformed(""), 400),
 			Entry("DeadlineExceededErrorType",
-				blaze.ErrorDeadlineExeeded("msg"), 408),
+				blaze.ErrorDeadlineExceeded("msg"), 408),
 			Entry("NotFoundErrorType:",
 				blaze.ErrorNotFound(""), 404),
 			Entry("BadRouteErrorType:",
@@ -99,6 +113,8 @@ var _ = Describe("Error", func() {
 				blaze.ErrorUnauthenticated(""), 401),
 			Entry("ResourceExhaustedErrorType",
 				blaze.ErrorResourceExhausted(""), 429),
+			Entry("ResourceExhaustedErrorType request too large",
+				blaze.ErrorRequestTooLarge(1024), 413),
 			Entry("FailedPreconditionErrorType",
 				blaze.ErrorFailedPrecondition(""), 412),
 			Entry("AbortedErrorType",
@@ -117,6 +133,130 @@ var _ = Describe("Error", func() {
 				blaze.ErrorDataLoss(""), 500),
 		)
 	})
+	Context("OtelServerCodeFromErrorType", func() {
+		var _ = DescribeTable("Error translation ",
+			func(err error, code otelc.Code) {
+				Expect(blaze.OtelServerCodeFromErrorType(err)).To(Equal(code))
+			},
+			Entry("nil", nil, otelc.Unset),
+			Entry("NotFoundErrorType", blaze.ErrorNotFound(""), otelc.Unset),
+			Entry("InvalidArgumentErrorType", blaze.ErrorInvalidArgument("", ""), otelc.Unset),
+			Entry("UnknownErrorType", blaze.ErrorUnknown(""), otelc.Error),
+			Entry("InternalErrorType", blaze.ErrorInternal(""), otelc.Error),
+			Entry("UnavailableErrorType", blaze.ErrorUnavailable(""), otelc.Error),
+			Entry("DeadlineExceededErrorType", blaze.ErrorDeadlineExceeded(""), otelc.Error),
+		)
+	})
+	Context("ClientErrorFromTransport", func() {
+		var _ = DescribeTable("Error classification ",
+			func(cause error, target interface{}) {
+				err := blaze.ClientErrorFromTransport(cause, "msg")
+				Expect(errors.As(err, target)).To(BeTrue())
+				Expect(errors.Is(err, cause)).To(BeTrue())
+			},
+			Entry("DeadlineExceeded", fmt.Errorf("do: %w", context.DeadlineExceeded), new(*blaze.DeadlineExceededErrorType)),
+			Entry("Canceled", fmt.Errorf("do: %w", context.Canceled), new(*blaze.CanceledErrorType)),
+			Entry("ConnectionRefused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, new(*blaze.UnavailableErrorType)),
+			Entry("Other", errors.New("other"), new(*blaze.InternalErrorType)),
+		)
+	})
+	Context("ErrorFromResponse", func() {
+		var _ = DescribeTable("Errors of intermediaries ",
+			func(status int, target interface{}, statusMeta string) {
+				err := blaze.ErrorFromResponse(&http.Response{
+					StatusCode: status,
+					Header:     http.Header{"Content-Type": {"text/html"}},
+					Body:       io.NopCloser(strings.NewReader("<html>proxy error</html>")),
+				})
+				Expect(errors.As(err, target)).To(BeTrue())
+				Expect(err.Meta(blaze.HTTPStatusMetaKey)).To(Equal(statusMeta))
+			},
+			Entry("Unauthorized", http.StatusUnauthorized, new(*blaze.UnauthenticatedErrorType), ""),
+			Entry("TooManyRequests", http.StatusTooManyRequests, new(*blaze.ResourceExhaustedErrorType), ""),
+			Entry("RequestEntityTooLarge", http.StatusRequestEntityTooLarge, new(*blaze.ResourceExhaustedErrorType), "413"),
+			Entry("BadGateway", http.StatusBadGateway, new(*blaze.UnavailableErrorType), ""),
+			Entry("Teapot", http.StatusTeapot, new(*blaze.UnknownErrorType), "418"),
+		)
+	})
+	Context("over the wire", func() {
+		var srv *httptest.Server
+		start := func(h health_v1.Health) health_v1.Health {
+			svc := health_v1.NewHealthService(h, logr.Discard())
+			mux := chi.NewMux()
+			mux.Mount(svc.MountPath(), svc.Mux())
+			srv = httptest.NewServer(mux)
+			DeferCleanup(srv.Close)
+			return health_v1.NewHealthClient(srv.URL, srv.Client())
+		}
+		check := func(ctx context.Context, client health_v1.Health) blaze.Error {
+			_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			Expect(err).To(HaveOccurred())
+			blerr, ok := err.(blaze.Error)
+			Expect(ok).To(BeTrue())
+			return blerr
+		}
+
+		var _ = DescribeTable("Errors of handlers ",
+			func(sent blaze.Error, status int) {
+				client := start(slowHealth{err: sent.WithMeta("k", "v")})
+				resp, err := srv.Client().Post(srv.URL+health_v1.HealthPathPrefix+"/Check", "application/protobuf", nil)
+				Expect(err).ToNot(HaveOccurred())
+				resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(status))
+				received := check(context.Background(), client)
+				Expect(received.Type()).To(Equal(sent.Type()))
+				Expect(received.Msg()).To(Equal(sent.Msg()))
+				Expect(received.Meta("k")).To(Equal("v"))
+				Expect(errors.Is(received, sent)).To(BeTrue())
+			},
+			Entry("CanceledErrorType With", blaze.ErrorCanceledWith(context.Canceled, "canceled by the backend"), http.StatusRequestTimeout),
+			Entry("DeadlineExceededErrorType With", blaze.ErrorDeadlineExceededWith(context.DeadlineExceeded, "the backend timed out"), http.StatusRequestTimeout),
+			Entry("UnavailableErrorType With", blaze.ErrorUnavailableWith(syscall.ECONNREFUSED, "the backend is down"), http.StatusServiceUnavailable),
+		)
+
+		It("reports canceled calls of clients", func() {
+			client := start(slowHealth{delay: time.Second})
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+			err := check(ctx, client)
+			Expect(errors.As(err, new(*blaze.CanceledErrorType))).To(BeTrue())
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+
+		It("reports exceeded deadlines of clients", func() {
+			client := start(slowHealth{delay: time.Second})
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := check(ctx, client)
+			Expect(errors.As(err, new(*blaze.DeadlineExceededErrorType))).To(BeTrue())
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+
+		It("reports unreachable servers as unavailable", func() {
+			start(servingHealth{})
+			srv.Close()
+			err := check(context.Background(), health_v1.NewHealthClient(srv.URL, http.DefaultClient))
+			Expect(errors.As(err, new(*blaze.UnavailableErrorType))).To(BeTrue())
+			Expect(errors.Is(err, syscall.ECONNREFUSED)).To(BeTrue())
+		})
+	})
+	Context("ServerDecodeError", func() {
+		var _ = DescribeTable("Json decode errors ",
+			func(body string, field string, position string) {
+				codec := blaze.NewJSONCodec()
+				codec.UnmarshalOptions.DiscardUnknown = false
+				cause := codec.Unmarshal([]byte(body), &descriptorpb.FileDescriptorProto{})
+				Expect(cause).ToNot(BeNil())
+				err := blaze.ServerDecodeError(codec, cause, []byte(body))
+				Expect(errors.As(err, new(*blaze.MalformedErrorType))).To(BeTrue())
+				Expect(err.Meta(blaze.FieldMetaKey)).To(Equal(field))
+				Expect(err.Meta(blaze.PositionMetaKey)).To(Equal(position))
+			},
+			Entry("InvalidValue", `{"name":"a","message_type":[{"name":"A"},{"field":[{"number":"x"}]}]}`, "message_type[1].field[0].number", "1:62"),
+			Entry("CamelCase", "{\n  \"messageType\": [{\"name\": 1}]\n}", "messageType[0].name", "2:28"),
+			Entry("UnknownField", `{"package":"p","bogus":true}`, "bogus", "1:16"),
+		)
+	})
 	Context("ErrorRegistry", func() {
 		It("can construct objects", func() {
 			oe := blaze.ErrorRequiredArgument("arg")
@@ -163,7 +303,7 @@ var _ = Describe("Error", func() {
 			Entry("MalformedErrorType",
 				blaze.ErrorMalformed("msg")),
 			Entry("DeadlineExceededErrorType",
-				blaze.ErrorDeadlineExeeded("msg")),
+				blaze.ErrorDeadlineExceeded("msg")),
 			Entry("NotFoundErrorType:",
 				blaze.ErrorNotFound("msg")),
 			Entry("BadRouteErrorType:",
@@ -0,0 +1,108 @@
+package blaze_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+type unknownHealth struct{}
+
+func (unknownHealth) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, blaze.ErrorNotFound("unknown service").WithMeta("service", in.GetService())
+}
+
+// contentTypes records the Content-Type of the responses of an http client
+type contentTypes struct {
+	client *http.Client
+	types  []string
+}
+
+func (c *contentTypes) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err == nil {
+		c.types = append(c.types, resp.Header.Get("Content-Type"))
+	}
+	return resp, err
+}
+
+var _ = Describe("Error body negotiation", func() {
+	var srv *httptest.Server
+
+	BeforeEach(func() {
+		svc := health_v1.NewHealthService(unknownHealth{}, logr.Discard())
+		mux := chi.NewMux()
+		mux.Mount(svc.MountPath(), svc.Mux())
+		srv = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	// post sends a protobuf request the way clients without the ErrorCodecsHeader do
+	post := func(header http.Header) *http.Response {
+		body, err := proto.Marshal(&grpc_health_v1.HealthCheckRequest{Service: "svc"})
+		Expect(err).ToNot(HaveOccurred())
+		req, err := http.NewRequest(http.MethodPost, srv.URL+health_v1.HealthPathPrefix+"/Check", bytes.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header = header
+		req.Header.Set("Content-Type", "application/protobuf")
+		req.Header.Set("Accept", "application/protobuf")
+		resp, err := srv.Client().Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		return resp
+	}
+
+	It("sends JSON errors to clients which do not list the response codec", func() {
+		resp := post(http.Header{})
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+		var ej blaze.ErrorJSON
+		Expect(json.NewDecoder(resp.Body).Decode(&ej)).To(Succeed())
+		Expect(ej.Type).To(Equal(blaze.ErrorNotFound("").Type()))
+		Expect(ej.Meta).To(HaveKeyWithValue("service", "svc"))
+	})
+
+	It("sends JSON errors to clients which list other codecs", func() {
+		resp := post(http.Header{blaze.ErrorCodecsHeader: {"json"}})
+		defer resp.Body.Close()
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+	})
+
+	It("sends errors in the response codec to clients which list it", func() {
+		resp := post(http.Header{blaze.ErrorCodecsHeader: {"json, protobuf"}})
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/protobuf"))
+		blerr := blaze.ErrorFromResponse(resp)
+		Expect(blerr.Type()).To(Equal(blaze.ErrorNotFound("").Type()))
+		Expect(blerr.Meta("service")).To(Equal("svc"))
+	})
+
+	DescribeTable("round trip with generated clients",
+		func(newClient func(string, blaze.HTTPClient, ...blaze.ClientOption) health_v1.Health, contentType string) {
+			client := &contentTypes{client: srv.Client()}
+			_, err := newClient(srv.URL, client).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "svc"})
+			Expect(err).To(HaveOccurred())
+			blerr := err.(blaze.Error)
+			Expect(blerr.Type()).To(Equal(blaze.ErrorNotFound("").Type()))
+			Expect(blerr.Msg()).To(Equal("unknown service"))
+			Expect(blerr.Meta("service")).To(Equal("svc"))
+			Expect(client.types).To(Equal([]string{contentType}))
+		},
+		Entry("protobuf", health_v1.NewHealthProtobufClient, "application/protobuf"),
+		Entry("json", health_v1.NewHealthJSONClient, "application/json"),
+	)
+})
@@ -0,0 +1,17 @@
+package blaze
+
+import "time"
+
+// Backoff exposes the wait time of a retry policy after an attempt to the tests
+func (p RetryPolicy) Backoff(attempt int) time.Duration { return p.backoff(attempt) }
+
+// JSONPathAt exposes the path of the json value at a line and column to the tests
+func JSONPathAt(data string, line, column int) string {
+	return jsonPathAt([]byte(data), jsonOffset([]byte(data), line, column))
+}
+
+// EncodeTimeout exposes the encoding of the Blaze-Timeout header to the tests
+func EncodeTimeout(timeout time.Duration) string { return encodeTimeout(timeout) }
+
+// DecodeTimeout exposes the decoding of the Blaze-Timeout header to the tests
+func DecodeTimeout(v string) (time.Duration, error) { return decodeTimeout(v) }
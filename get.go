@@ -0,0 +1,87 @@
+package blaze
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// MessageQueryParam is the query parameter carrying the request message of GET requests
+	MessageQueryParam = "message"
+	// EncodingQueryParam is the query parameter naming the codec of the request message of GET requests
+	EncodingQueryParam = "encoding"
+	// MaxGetURLLength is the length of an url up to which clients use GET for methods without side effects.
+	// Requests with longer urls are sent with POST
+	MaxGetURLLength = 4096
+)
+
+// NewGetHTTPRequest creates a GET request for a method without side effects, carrying the encoded
+// request message in the query. Messages of the json codec are sent as is, the messages of other
+// codecs are base64url encoded. It falls back to POST if the url would exceed MaxGetURLLength
+func NewGetHTTPRequest(ctx context.Context, rawURL string, body []byte, codec Codec, version string) (*http.Request, error) {
+	message := string(body)
+	if codec.Name() != CodecNameJSON {
+		message = base64.RawURLEncoding.EncodeToString(body)
+	}
+	query := url.Values{MessageQueryParam: {message}, EncodingQueryParam: {codec.Name()}}
+	getURL := rawURL + "?" + query.Encode()
+	if len(getURL) > MaxGetURLLength {
+		return NewHTTPRequest(ctx, rawURL, bytes.NewReader(body), codec.ContentTypes()[0], version)
+	}
+	req, err := newHTTPRequest(ctx, http.MethodGet, getURL, nil, version)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", codec.ContentTypes()[0])
+	req.Header.Set(ErrorCodecsHeader, codec.Name())
+	return req, nil
+}
+
+// RequestCodec returns the codec of a request to a service. GET requests name the codec
+// in the encoding query parameter, other requests in the Content-Type header.
+// It returns blaze errors to be written with ServerWriteError
+func (o *ServiceOptions) RequestCodec(req *http.Request) (Codec, error) {
+	if req.Method == http.MethodGet {
+		name := req.URL.Query().Get(EncodingQueryParam)
+		if c, ok := CodecByName(name); ok {
+			if c, ok := o.Codec(c.ContentTypes()[0]); ok {
+				return c, nil
+			}
+		}
+		msg := "unexpected encoding: " + name
+		return nil, ServerInvalidRequestError(EncodingQueryParam, msg, req.Method, req.URL.Path)
+	}
+	if c, ok := o.Codec(req.Header.Get("Content-Type")); ok {
+		return c, nil
+	}
+	msg := "unexpected Content-Type: \"" + req.Header.Get("Content-Type") + "\""
+	return nil, ServerInvalidRequestError("Content-Type", msg, req.Method, req.URL.Path)
+}
+
+// ReadRequest reads the encoded request message of a request to a service. GET requests carry
+// it in the message query parameter, base64url encoded unless the codec is the json codec.
+// Other requests are read with ReadRequestBody. It returns blaze errors to be written with ServerWriteError
+func ReadRequest(resp http.ResponseWriter, req *http.Request, codec Codec, limit int64) ([]byte, error) {
+	if req.Method != http.MethodGet {
+		return ReadRequestBody(resp, req, limit)
+	}
+	if limit == 0 {
+		limit = DefaultMaxRequestBytes
+	}
+	message := req.URL.Query().Get(MessageQueryParam)
+	if limit > 0 && int64(len(message)) > limit {
+		return nil, ErrorRequestTooLarge(limit)
+	}
+	if codec.Name() == CodecNameJSON {
+		return []byte(message), nil
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(message, "="))
+	if err != nil {
+		return nil, ErrorMalformed("the " + MessageQueryParam + " query parameter is not base64url encoded")
+	}
+	return buf, nil
+}
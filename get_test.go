@@ -0,0 +1,141 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"code.cestus.io/blaze"
+)
+
+var _ = Describe("GET requests", func() {
+	var options blaze.ServiceOptions
+
+	// roundTrip sends the request of NewGetHTTPRequest to a server reading it like a generated service
+	roundTrip := func(codec blaze.Codec, body []byte) (*http.Request, []byte, error) {
+		req, err := blaze.NewGetHTTPRequest(context.Background(), "http://localhost/svc/Method", body, codec, "")
+		Expect(err).ToNot(HaveOccurred())
+		serverCodec, err := options.RequestCodec(req)
+		if err != nil {
+			return req, nil, err
+		}
+		Expect(serverCodec.Name()).To(Equal(codec.Name()))
+		buf, err := blaze.ReadRequest(httptest.NewRecorder(), req, serverCodec, options.MaxRequestBytes)
+		return req, buf, err
+	}
+
+	DescribeTable("carry the request message in the query",
+		func(codec blaze.Codec) {
+			body, err := codec.Marshal(wrapperspb.String("a query / with & special = characters"))
+			Expect(err).ToNot(HaveOccurred())
+			req, buf, err := roundTrip(codec, body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(req.Method).To(Equal(http.MethodGet))
+			Expect(req.Header.Get("Accept")).To(Equal(codec.ContentTypes()[0]))
+			Expect(req.URL.Query().Get(blaze.EncodingQueryParam)).To(Equal(codec.Name()))
+			Expect(buf).To(Equal(body))
+		},
+		Entry("json", blaze.NewJSONCodec()),
+		Entry("protobuf", blaze.ProtobufCodec{}),
+	)
+
+	It("falls back to POST for long messages", func() {
+		body := []byte(`"` + strings.Repeat("a", blaze.MaxGetURLLength) + `"`)
+		req, buf, err := roundTrip(blaze.NewJSONCodec(), body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Method).To(Equal(http.MethodPost))
+		Expect(buf).To(Equal(body))
+	})
+
+	It("rejects unknown encodings", func() {
+		req := httptest.NewRequest(http.MethodGet, "/svc/Method?encoding=xml&message=x", nil)
+		_, err := options.RequestCodec(req)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorInvalidArgument("", "").Type()))
+		Expect(err.(blaze.Error).Msg()).To(ContainSubstring("unexpected encoding: xml"))
+	})
+
+	It("rejects messages which are not base64url encoded", func() {
+		req := httptest.NewRequest(http.MethodGet, "/svc/Method?encoding=protobuf&message=%2B%2F", nil)
+		_, err := blaze.ReadRequest(httptest.NewRecorder(), req, blaze.ProtobufCodec{}, 0)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).Type()).To(Equal(blaze.ErrorMalformed("").Type()))
+	})
+
+	It("limits the size of the message", func() {
+		req := httptest.NewRequest(http.MethodGet, "/svc/Method?encoding=json&message=%22abcdef%22", nil)
+		_, err := blaze.ReadRequest(httptest.NewRecorder(), req, blaze.NewJSONCodec(), 4)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(blaze.Error).MetaMap()).To(HaveKeyWithValue(blaze.MaxRequestBytesMetaKey, "4"))
+	})
+})
+
+var _ = Describe("ServerNotModified", func() {
+	// notModified answers a request with the If-None-Match header to a response with the ETag
+	notModified := func(method, ifNoneMatch, etag string) (bool, int) {
+		req := httptest.NewRequest(method, "/svc/Method", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		ctx := blaze.WithServerMetadata(req.Context(), req)
+		if etag != "" {
+			blaze.SetETag(ctx, etag)
+		}
+		blaze.WriteResponseMetadata(ctx, rec)
+		written := blaze.ServerNotModified(rec, req)
+		return written, rec.Code
+	}
+
+	DescribeTable("compares If-None-Match with the ETag",
+		func(method, ifNoneMatch, etag string, expected bool) {
+			written, code := notModified(method, ifNoneMatch, etag)
+			Expect(written).To(Equal(expected))
+			if expected {
+				Expect(code).To(Equal(http.StatusNotModified))
+			}
+		},
+		Entry("matching tag", http.MethodGet, `"v1"`, "v1", true),
+		Entry("other tag", http.MethodGet, `"v2"`, "v1", false),
+		Entry("tag in a list", http.MethodGet, `"v0", "v1"`, "v1", true),
+		Entry("weak tag", http.MethodGet, `W/"v1"`, `"v1"`, true),
+		Entry("weak ETag", http.MethodGet, `"v1"`, `W/"v1"`, true),
+		Entry("any tag", http.MethodGet, `*`, "v1", true),
+		Entry("without If-None-Match", http.MethodGet, "", "v1", false),
+		Entry("without ETag", http.MethodGet, `*`, "", false),
+		Entry("POST request", http.MethodPost, `"v1"`, "v1", false),
+	)
+})
+
+var _ = Describe("Cache headers", func() {
+	It("are sent with successful responses", func() {
+		req := httptest.NewRequest(http.MethodGet, "/svc/Method", nil)
+		ctx := blaze.WithServerMetadata(req.Context(), req)
+		blaze.SetCacheControl(ctx, "max-age=60")
+		blaze.SetETag(ctx, "v1")
+		rec := httptest.NewRecorder()
+		blaze.WriteResponseMetadata(ctx, rec)
+		Expect(rec.Header().Get("Cache-Control")).To(Equal("max-age=60"))
+		Expect(rec.Header().Get("ETag")).To(Equal(`"v1"`))
+	})
+
+	It("are not sent with errors", func() {
+		req := httptest.NewRequest(http.MethodGet, "/svc/Method", nil)
+		ctx := blaze.WithServerMetadata(req.Context(), req)
+		blaze.SetCacheControl(ctx, "max-age=60")
+		blaze.SetETag(ctx, "v1")
+		blaze.SetHeader(ctx, "X-Test", "1")
+		rec := httptest.NewRecorder()
+		blaze.ServerWriteError(ctx, rec, blaze.ErrorNotFound("gone"), logr.Discard())
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Header()).ToNot(HaveKey("Cache-Control"))
+		Expect(rec.Header()).ToNot(HaveKey("Etag"))
+		Expect(rec.Header().Get("X-Test")).To(Equal("1"))
+	})
+})
@@ -0,0 +1,220 @@
+package blaze
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cestus.io/blaze/pkg/blazemetrics"
+)
+
+// HedgingOption is a functional option for extending a hedging client
+type HedgingOption func(*hedgingClient)
+
+// WithHedgeDelay sets the time after which a hedged copy of a call is sent for methods without a delay of their own
+func WithHedgeDelay(delay time.Duration) HedgingOption {
+	return func(c *hedgingClient) {
+		c.delay = delay
+	}
+}
+
+// WithMethodHedgeDelay sets the hedge delay of a single method given in the form Service/Method. A delay of 0 disables hedging
+func WithMethodHedgeDelay(method string, delay time.Duration) HedgingOption {
+	return func(c *hedgingClient) {
+		c.methodDelays[method] = delay
+	}
+}
+
+// WithHedgingMetrics replaces the default metrics
+func WithHedgingMetrics(metrics blazemetrics.HedgingMetrics) HedgingOption {
+	return func(c *hedgingClient) {
+		c.metrics = metrics
+	}
+}
+
+type hedgingClient struct {
+	client       HTTPClient
+	delay        time.Duration
+	methodDelays map[string]time.Duration
+	metrics      blazemetrics.HedgingMetrics
+}
+
+// NewHedgingClient wraps client to send a second copy of calls to idempotent methods which are not answered
+// within the hedge delay. The first successful response is returned and the other call is cancelled.
+// A call failing transiently with an unavailable server or a transport error before the delay is hedged
+// at once, other errors are returned without waiting for the copy. Wrapping a balancing client makes the copy go to another endpoint
+func NewHedgingClient(client HTTPClient, opts ...HedgingOption) HTTPClient {
+	c := &hedgingClient{
+		client:       client,
+		delay:        100 * time.Millisecond,
+		methodDelays: map[string]time.Duration{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.metrics == nil {
+		c.metrics = blazemetrics.NewHedgingMetrics()
+	}
+	return c
+}
+
+type hedgeResult struct {
+	resp   *http.Response
+	err    error
+	blerr  Error
+	hedge  bool
+	cancel context.CancelFunc
+	// transient marks failures which the other copy may not share: transport errors and unavailable servers
+	transient bool
+}
+
+// transientFailure reports whether an attempt failed on the transport or with an unavailable server
+func transientFailure(blerr Error, err error) bool {
+	if blerr == nil {
+		return false
+	}
+	return err != nil || blerr.Type() == ErrorUnavailable("").Type()
+}
+
+func (c *hedgingClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	info, ok := MethodInfoFromContext(ctx)
+	if !ok || !info.Idempotency.IsIdempotent() {
+		return c.client.Do(req)
+	}
+	delay, ok := c.methodDelays[info.FullName()]
+	if !ok {
+		delay = c.delay
+	}
+	if delay <= 0 {
+		return c.client.Do(req)
+	}
+	getBody, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withEndpointExclusion(ctx, &endpointExclusion{})
+	results := make(chan hedgeResult, 2)
+	launch := func(hedge bool) {
+		actx, cancel := context.WithCancel(ctx)
+		r := req.Clone(actx)
+		// the copy has less time left than the first call
+		if deadline, ok := ctx.Deadline(); ok {
+			r.Header.Set(TimeoutHeader, encodeTimeout(time.Until(deadline)))
+		}
+		body, err := getBody()
+		if err != nil {
+			cancel()
+			results <- hedgeResult{err: err, blerr: ErrorInternalWith(err, "failed to replay request body"), hedge: hedge, cancel: cancel}
+			return
+		}
+		r.Body = body
+		go func() {
+			resp, err := c.client.Do(r)
+			var blerr Error
+			blerr, resp = classifyAttempt(resp, err)
+			results <- hedgeResult{resp: resp, err: err, blerr: blerr, hedge: hedge, cancel: cancel, transient: transientFailure(blerr, err)}
+		}()
+	}
+
+	launch(false)
+	pending, hedged := 1, false
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedge := func() {
+		hedged = true
+		pending++
+		c.metrics.Hedged(ctx, info.Service, info.Method)
+		launch(true)
+	}
+	for {
+		select {
+		case <-timer.C:
+			if !hedged && ctx.Err() == nil {
+				hedge()
+			}
+		case res := <-results:
+			pending--
+			if res.transient && !hedged && ctx.Err() == nil {
+				// the first call failed transiently before the delay, the copy is the remaining chance
+				hedge()
+			}
+			if res.transient && pending > 0 {
+				discardResult(res)
+				continue
+			}
+			if pending > 0 {
+				go drainResults(results, pending)
+			}
+			if res.hedge && res.blerr == nil {
+				c.metrics.HedgeWon(ctx, info.Service, info.Method)
+			}
+			if res.resp != nil {
+				res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: res.cancel}
+			} else {
+				res.cancel()
+			}
+			return res.resp, res.err
+		}
+	}
+}
+
+// drainResults cancels and closes the calls which lost the race
+func drainResults(results chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		discardResult(<-results)
+	}
+}
+
+func discardResult(res hedgeResult) {
+	res.cancel()
+	if res.resp != nil {
+		_ = res.resp.Body.Close()
+	}
+}
+
+// cancelOnClose releases the context of the winning call once its response is consumed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// endpointExclusion collects the endpoints used by the copies of a hedged call
+type endpointExclusion struct {
+	mu        sync.Mutex
+	addresses map[string]bool
+}
+
+func (e *endpointExclusion) add(address string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.addresses == nil {
+		e.addresses = map[string]bool{}
+	}
+	e.addresses[address] = true
+}
+
+func (e *endpointExclusion) contains(address string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.addresses[address]
+}
+
+type endpointExclusionKey struct{}
+
+func withEndpointExclusion(ctx context.Context, e *endpointExclusion) context.Context {
+	return context.WithValue(ctx, endpointExclusionKey{}, e)
+}
+
+func endpointExclusionFromContext(ctx context.Context) *endpointExclusion {
+	e, _ := ctx.Value(endpointExclusionKey{}).(*endpointExclusion)
+	return e
+}
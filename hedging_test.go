@@ -0,0 +1,239 @@
+package blaze_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+// attempt answers the n-th request (starting at 0) sent by a scriptedClient
+type attempt func(req *http.Request) (*http.Response, error)
+
+// scriptedClient answers each request with the next attempt and records the context of each request
+type scriptedClient struct {
+	mu       sync.Mutex
+	attempts []attempt
+	contexts []context.Context
+}
+
+func (c *scriptedClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	n := len(c.contexts)
+	c.contexts = append(c.contexts, req.Context())
+	c.mu.Unlock()
+	return c.attempts[n](req)
+}
+
+func (c *scriptedClient) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.contexts)
+}
+
+func (c *scriptedClient) context(n int) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.contexts[n]
+}
+
+func respond(status int, body string) attempt {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	}
+}
+
+func after(d time.Duration, a attempt) attempt {
+	return func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-time.After(d):
+			return a(req)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func fail(err error) attempt {
+	return func(*http.Request) (*http.Response, error) { return nil, err }
+}
+
+type countingHedgingMetrics struct {
+	hedged, won int32
+}
+
+func (m *countingHedgingMetrics) Hedged(context.Context, string, string) {
+	atomic.AddInt32(&m.hedged, 1)
+}
+func (m *countingHedgingMetrics) HedgeWon(context.Context, string, string) {
+	atomic.AddInt32(&m.won, 1)
+}
+
+func idempotentRequest(ctx context.Context, level blaze.IdempotencyLevel) *http.Request {
+	ctx = blaze.WithMethodInfo(ctx, blaze.MethodInfo{Service: "Svc", Method: "Method", Idempotency: level})
+	req, _ := http.NewRequestWithContext(ctx, "POST", "http://localhost/svc/Method", strings.NewReader("payload"))
+	return req
+}
+
+func readBody(resp *http.Response) string {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	return string(body)
+}
+
+var _ = Describe("HedgingClient", func() {
+	var (
+		inner   *scriptedClient
+		metrics *countingHedgingMetrics
+		client  blaze.HTTPClient
+	)
+	BeforeEach(func() {
+		inner = &scriptedClient{}
+		metrics = &countingHedgingMetrics{}
+		client = blaze.NewHedgingClient(inner, blaze.WithHedgeDelay(20*time.Millisecond), blaze.WithHedgingMetrics(metrics))
+	})
+
+	It("does not hedge a call answered within the delay", func() {
+		inner.attempts = []attempt{respond(200, "first")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("first"))
+		Expect(inner.calls()).To(Equal(1))
+		Expect(metrics.hedged).To(BeZero())
+	})
+
+	It("sends a copy after the delay and cancels the slow call once the copy wins", func() {
+		inner.attempts = []attempt{after(time.Second, respond(200, "first")), respond(200, "hedge")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("hedge"))
+		Expect(atomic.LoadInt32(&metrics.hedged)).To(BeEquivalentTo(1))
+		Expect(atomic.LoadInt32(&metrics.won)).To(BeEquivalentTo(1))
+		Eventually(inner.context(0).Done()).Should(BeClosed())
+	})
+
+	It("returns the first call if it wins against the copy", func() {
+		inner.attempts = []attempt{after(60*time.Millisecond, respond(200, "first")), after(time.Second, respond(200, "hedge"))}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("first"))
+		Expect(atomic.LoadInt32(&metrics.hedged)).To(BeEquivalentTo(1))
+		Expect(atomic.LoadInt32(&metrics.won)).To(BeZero())
+		Eventually(inner.context(1).Done()).Should(BeClosed())
+	})
+
+	It("replays the body for the copy", func() {
+		body := func(req *http.Request) (*http.Response, error) {
+			b, _ := io.ReadAll(req.Body)
+			return respond(200, string(b))(req)
+		}
+		inner.attempts = []attempt{after(time.Second, body), body}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("payload"))
+	})
+
+	It("hedges at once if the first call fails with an unavailable server", func() {
+		client = blaze.NewHedgingClient(inner, blaze.WithHedgeDelay(time.Hour), blaze.WithHedgingMetrics(metrics))
+		inner.attempts = []attempt{respond(503, "down"), respond(200, "hedge")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("hedge"))
+	})
+
+	It("hedges at once if the first call fails on the transport", func() {
+		client = blaze.NewHedgingClient(inner, blaze.WithHedgeDelay(time.Hour), blaze.WithHedgingMetrics(metrics))
+		inner.attempts = []attempt{fail(errors.New("connection lost")), respond(200, "hedge")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("hedge"))
+	})
+
+	It("returns other errors without hedging", func() {
+		client = blaze.NewHedgingClient(inner, blaze.WithHedgeDelay(time.Hour), blaze.WithHedgingMetrics(metrics))
+		inner.attempts = []attempt{respond(400, "bad request")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(400))
+		Expect(readBody(resp)).To(Equal("bad request"))
+		Expect(inner.calls()).To(Equal(1))
+		Expect(metrics.hedged).To(BeZero())
+	})
+
+	It("returns the last error if both copies fail", func() {
+		inner.attempts = []attempt{after(40*time.Millisecond, respond(503, "first")), respond(503, "hedge")}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(readBody(resp)).To(Equal("first"))
+	})
+
+	It("does not hedge methods which are not idempotent", func() {
+		inner.attempts = []attempt{after(60*time.Millisecond, respond(200, "first"))}
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.IdempotencyUnknown))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("first"))
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("does not hedge methods with a delay of 0", func() {
+		client = blaze.NewHedgingClient(inner, blaze.WithMethodHedgeDelay("Svc/Method", 0))
+		inner.attempts = []attempt{after(60*time.Millisecond, respond(200, "first"))}
+		_, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("recomputes the timeout of the copy from the remaining deadline", func() {
+		timeout := func(req *http.Request) (*http.Response, error) {
+			return respond(200, req.Header.Get(blaze.TimeoutHeader))(req)
+		}
+		inner.attempts = []attempt{after(time.Second, timeout), timeout}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		req := idempotentRequest(ctx, blaze.Idempotent)
+		req.Header.Set(blaze.TimeoutHeader, "1000")
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		ms, err := strconv.Atoi(readBody(resp))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ms).To(BeNumerically("<=", 980))
+		Expect(ms).To(BeNumerically(">", 0))
+	})
+
+	It("does not hedge once the caller is done", func() {
+		ignoringCancel := func(req *http.Request) (*http.Response, error) {
+			time.Sleep(60 * time.Millisecond)
+			return nil, req.Context().Err()
+		}
+		inner.attempts = []attempt{ignoringCancel, respond(200, "hedge")}
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(5*time.Millisecond, cancel)
+		_, err := client.Do(idempotentRequest(ctx, blaze.Idempotent))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(inner.calls()).To(Equal(1))
+		Expect(atomic.LoadInt32(&metrics.hedged)).To(BeZero())
+	})
+
+	It("cancels both copies if the caller cancels", func() {
+		inner.attempts = []attempt{after(time.Hour, respond(200, "first")), after(time.Hour, respond(200, "hedge"))}
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(60*time.Millisecond, cancel)
+		_, err := client.Do(idempotentRequest(ctx, blaze.Idempotent))
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(inner.calls()).To(Equal(2))
+		Eventually(inner.context(0).Done()).Should(BeClosed())
+		Eventually(inner.context(1).Done()).Should(BeClosed())
+	})
+})
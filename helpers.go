@@ -32,13 +32,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"syscall"
+	"time"
 
+	"code.cestus.io/blaze/pkg/blazemetrics"
+	"code.cestus.io/blaze/pkg/blazetrace"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 //ServerBadRouteError is used when the blaze server cannot route a request
@@ -56,19 +64,40 @@ func ServerInvalidRequestError(argument string, validationMsg string, method, ur
 }
 
 // ServerWriteError writes Blaze errors in the response and triggers hooks.
+// Error bodies are JSON unless the request lists the negotiated response codec in the ErrorCodecsHeader.
+// The request logger of the context is preferred over log.
 func ServerWriteError(ctx context.Context, resp http.ResponseWriter, err error, log logr.Logger) {
+	log = loggerFromContextOr(ctx, log)
 	// Non-blaze errors are wrapped as Internal (default)
+	// all errors after the deadline of the request and exceeded deadlines of the handler are reported as exceeded deadline
+	err = ServerDeadlineError(ctx, err)
 	blerr, ok := err.(Error)
-	if !ok {
+	if !ok && errors.Is(err, context.DeadlineExceeded) {
+		blerr = ErrorDeadlineExceededWith(err, "the deadline of the request was exceeded")
+	} else if !ok {
 		blerr = ErrorInternalWith(err, "")
 	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		blerr = blerr.WithMeta(RequestIDMetaKey, id)
+	}
+	blazemetrics.CallFromContext(ctx).SetError(blerr)
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(blazetrace.ErrorTypeKey.String(blerr.Type()))
+	blazetrace.RecordError(ctx, blerr.Type(), blerr.Msg())
+	span.SetStatus(OtelServerCodeFromErrorType(blerr), blerr.Msg())
 
 	statusCode := ServerHTTPStatusFromErrorType(blerr)
 
-	respBody := marshalErrorToJSON(blerr)
+	respBody, contentType := marshalError(blerr, errorCodec(ctx))
+
+	WriteResponseMetadata(ctx, resp)
+	// errors must not be cached as the response of the request
+	for _, k := range cacheHeaders {
+		resp.Header().Del(k)
+	}
 
-	resp.Header().Set("Content-Type", "application/json") // Error responses are always JSON
-	resp.Header().Set("Content-Length", strconv.Itoa(len(respBody)))
+	resp.Header().Set("Content-Type", contentType) // JSON unless the client accepts errors in the negotiated response codec
+	SetContentLength(resp, len(respBody))
 	resp.WriteHeader(statusCode) // set HTTP status code and send response
 
 	_, writeErr := resp.Write(respBody)
@@ -80,13 +109,16 @@ func ServerWriteError(ctx context.Context, resp http.ResponseWriter, err error,
 }
 
 // ServerEnsurePanicResponses esure panic responses
+// The request logger of the context is preferred over log.
 func ServerEnsurePanicResponses(ctx context.Context, resp http.ResponseWriter, log logr.Logger) {
 	if r := recover(); r != nil {
+		log = loggerFromContextOr(ctx, log)
 		// Wrap the panic as an error so it can be passed to error hooks.
 		// The original error is accessible from error hooks, but not visible in the response.
 		// After hooks are implemented that is :)
 		err := errFromPanic(r)
 		blerr := ErrorInternalWith(err, "Internal service panic")
+		log.Error(err, "Internal service panic")
 		// Actually write the error
 		ServerWriteError(ctx, resp, blerr, log)
 		// If possible, flush the error to the wire.
@@ -99,6 +131,23 @@ func ServerEnsurePanicResponses(ctx context.Context, resp http.ResponseWriter, l
 	}
 }
 
+// marshalError encodes a blaze.Error with the codec and returns the body and its content type.
+// Errors are encoded as JSON without a codec, with the json codec or if the codec fails.
+// Other codecs encode a google.protobuf.Struct with the fields of the JSON body: the string fields
+// code, msg and blaze_type and the struct meta with string values.
+func marshalError(blerr Error, codec Codec) ([]byte, string) {
+	if _, isJSON := codec.(*JSONCodec); codec == nil || isJSON {
+		return marshalErrorToJSON(blerr), "application/json"
+	}
+	be, err := ErrorToErrorJSON(blerr)
+	if err == nil {
+		if buf, err := codec.Marshal(errorJSONToStruct(be)); err == nil {
+			return buf, codec.ContentTypes()[0]
+		}
+	}
+	return marshalErrorToJSON(blerr), "application/json"
+}
+
 // marshalErrorToJSON returns JSON from a blaze.Error, that can be used as HTTP error response body.
 // If serialization fails, it will use a descriptive Internal error instead.
 func marshalErrorToJSON(blerr Error) []byte {
@@ -163,15 +212,13 @@ func ErrorFromResponse(resp *http.Response) Error {
 		return blazeErrorFromIntermediary(statusCode, msg, location)
 	}
 
-	respBodyBytes, err := io.ReadAll(resp.Body)
+	respBodyBytes, err := ReadResponseBody(resp)
 	if err != nil {
 		return ErrorInternalWith(err, "failed to read server error response body")
 	}
 
-	var ej ErrorJSON
-	dec := json.NewDecoder(bytes.NewReader(respBodyBytes))
-	dec.DisallowUnknownFields()
-	if err := dec.Decode(&ej); err != nil || ej.Code == "" {
+	ej, err := unmarshalError(resp.Header.Get("Content-Type"), respBodyBytes)
+	if err != nil || ej.Code == "" {
 		// Invalid JSON response; it must be an error from an intermediary.
 		msg := fmt.Sprintf("Error from intermediary with HTTP status code %d %q", statusCode, statusText)
 		return blazeErrorFromIntermediary(statusCode, msg, string(respBodyBytes))
@@ -183,6 +230,29 @@ func ErrorFromResponse(resp *http.Response) Error {
 	return blerr
 }
 
+// unmarshalError decodes an error body with the registered codec of its content type.
+// Bodies of unknown content types are decoded as JSON.
+func unmarshalError(contentType string, body []byte) (ErrorJSON, error) {
+	var ej ErrorJSON
+	if codec, ok := CodecForContentType(contentType); ok {
+		if _, isJSON := codec.(*JSONCodec); !isJSON {
+			s := &structpb.Struct{}
+			if err := codec.Unmarshal(body, s); err != nil {
+				return ej, err
+			}
+			return errorJSONFromStruct(s), nil
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&ej)
+	return ej, err
+}
+
+// HTTPStatusMetaKey is the meta key stating the HTTP status of errors responded by intermediaries
+// like proxies instead of a blaze service
+const HTTPStatusMetaKey = "code"
+
 // blazeErrorFromIntermediary maps HTTP errors from non-twirp sources to twirp errors.
 // The mapping is similar to gRPC: https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md.
 // Returned twirp Errors have some additional metadata for inspection.
@@ -200,13 +270,15 @@ func blazeErrorFromIntermediary(status int, msg string, bodyOrLocation string) E
 			blerr = ErrorPermissionDenied(msg)
 		case 404: // Not Found
 			blerr = ErrorBadRoute(msg)
+		case 413: // Request Entity Too Large
+			blerr = ErrorResourceExhausted(msg).WithMeta(HTTPStatusMetaKey, strconv.Itoa(status))
 		case 429:
 			blerr = ErrorResourceExhausted(msg)
 		case 502, 503, 504: //  Bad Gateway, Service Unavailable, Gateway Timeout
 			blerr = ErrorUnavailable(msg)
 		default: // All other codes
 			blerr = ErrorUnknown("From intermediary")
-			blerr = blerr.WithMeta("code", strconv.Itoa(status))
+			blerr = blerr.WithMeta(HTTPStatusMetaKey, strconv.Itoa(status))
 		}
 	}
 	return blerr
@@ -231,15 +303,55 @@ func UrlBase(addr string) string {
 	return url.String()
 }
 
+// ClientErrorFromTransport classifies errors of sending a request and receiving its response.
+// Exceeded deadlines, cancellations and failures to connect to the server get their blaze error type,
+// blaze errors of HTTPClient implementations are returned as is and all other errors are internal. The original error is wrapped
+func ClientErrorFromTransport(err error, msg string) Error {
+	var blerr Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &blerr):
+		return blerr
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorDeadlineExceededWith(err, msg)
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceledWith(err, msg)
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.As(err, &dnsErr):
+		return ErrorUnavailableWith(err, msg)
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ErrorUnavailableWith(err, msg)
+	default:
+		return ErrorInternalWith(err, msg)
+	}
+}
+
 // NewHTTPRequest creates a httprequest for a client, adding common headers.
 func NewHTTPRequest(ctx context.Context, url string, reqBody io.Reader, contentType string, version string) (*http.Request, error) {
-	req, err := http.NewRequest("POST", url, reqBody)
+	req, err := newHTTPRequest(ctx, "POST", url, reqBody, version)
 	if err != nil {
 		return nil, err
 	}
-	req = req.WithContext(ctx)
 	req.Header.Set("Accept", contentType)
 	req.Header.Set("Content-Type", contentType)
+	if c, ok := CodecForContentType(contentType); ok {
+		req.Header.Set(ErrorCodecsHeader, c.Name())
+	}
+	return req, nil
+}
+
+// newHTTPRequest creates a httprequest with the headers common to all methods
+func newHTTPRequest(ctx context.Context, method string, url string, reqBody io.Reader, version string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Blaze-Version", version)
+	if id := RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(TimeoutHeader, encodeTimeout(time.Until(deadline)))
+	}
 	return req, nil
 }
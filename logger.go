@@ -0,0 +1,40 @@
+package blaze
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithLogger adds the logger to the context. Handler implementations retrieve it with LoggerFromContext
+func WithLogger(ctx context.Context, log logr.Logger) context.Context {
+	return logr.NewContext(ctx, log)
+}
+
+// LoggerFromContext returns the request logger of the context.
+// If the context does not carry a logger a logger discarding all messages is returned
+func LoggerFromContext(ctx context.Context) logr.Logger {
+	return logr.FromContextOrDiscard(ctx)
+}
+
+// WithRequestLogger adds a logger for a single request to the context.
+// The logger is enriched with the service, method, request ID and the trace and span IDs of the span in the context
+func WithRequestLogger(ctx context.Context, log logr.Logger, service string, method string) context.Context {
+	log = log.WithValues("service", service, "method", method)
+	if id := RequestIDFromContext(ctx); id != "" {
+		log = log.WithValues("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		log = log.WithValues("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	return WithLogger(ctx, log)
+}
+
+// loggerFromContextOr returns the request logger of the context or log if there is none
+func loggerFromContextOr(ctx context.Context, log logr.Logger) logr.Logger {
+	if l, err := logr.FromContext(ctx); err == nil {
+		return l
+	}
+	return log
+}
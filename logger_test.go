@@ -0,0 +1,157 @@
+package blaze_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+// logLines records the lines written to a logger
+type logLines struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *logLines) logger() logr.Logger {
+	return funcr.New(func(prefix, args string) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.lines = append(l.lines, args)
+	}, funcr.Options{})
+}
+
+func (l *logLines) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.lines...)
+}
+
+// loggingHealth logs with the request logger of the context
+type loggingHealth struct{}
+
+func (loggingHealth) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	blaze.LoggerFromContext(ctx).Info("checking")
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// failingWriter fails to write the body of responses
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+var _ = Describe("Request loggers", func() {
+	var logs *logLines
+
+	BeforeEach(func() {
+		logs = &logLines{}
+	})
+
+	It("carry the service, method, request ID and trace", func() {
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{2},
+		})
+		ctx := trace.ContextWithSpanContext(blaze.WithRequestID(context.Background(), "req-1"), sc)
+		ctx = blaze.WithRequestLogger(ctx, logs.logger(), "Svc", "Method")
+		blaze.LoggerFromContext(ctx).Info("hello")
+		Expect(logs.get()).To(ConsistOf(SatisfyAll(
+			ContainSubstring(`"msg"="hello"`),
+			ContainSubstring(`"service"="Svc"`),
+			ContainSubstring(`"method"="Method"`),
+			ContainSubstring(`"request_id"="req-1"`),
+			ContainSubstring(`"trace_id"="`+sc.TraceID().String()+`"`),
+			ContainSubstring(`"span_id"="`+sc.SpanID().String()+`"`),
+		)))
+	})
+
+	It("leave out missing request IDs and traces", func() {
+		ctx := blaze.WithRequestLogger(context.Background(), logs.logger(), "Svc", "Method")
+		blaze.LoggerFromContext(ctx).Info("hello")
+		Expect(logs.get()).To(ConsistOf(SatisfyAll(
+			ContainSubstring(`"service"="Svc"`),
+			Not(ContainSubstring("request_id")),
+			Not(ContainSubstring("trace_id")),
+		)))
+	})
+
+	It("discard messages without a logger in the context", func() {
+		Expect(blaze.LoggerFromContext(context.Background()).GetSink()).To(BeNil())
+	})
+
+	It("reach the handlers of generated services", func() {
+		svc := health_v1.NewHealthService(loggingHealth{}, logs.logger())
+		mux := chi.NewMux()
+		mux.Use(server.RequestID)
+		mux.Mount(svc.MountPath(), svc.Mux())
+		req := httptest.NewRequest(http.MethodPost, health_v1.HealthPathPrefix+"/Check", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(blaze.RequestIDHeader, "req-2")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(logs.get()).To(ContainElement(SatisfyAll(
+			ContainSubstring(`"msg"="checking"`),
+			ContainSubstring(`"service"="Health"`),
+			ContainSubstring(`"method"="Check"`),
+			ContainSubstring(`"request_id"="req-2"`),
+		)))
+	})
+
+	Context("of errors", func() {
+		var (
+			fallback *logLines
+			ctx      context.Context
+		)
+
+		BeforeEach(func() {
+			fallback = &logLines{}
+			ctx = blaze.WithRequestLogger(blaze.WithRequestID(context.Background(), "req-3"), logs.logger(), "Svc", "Method")
+		})
+
+		It("are preferred by ServerWriteError", func() {
+			blaze.ServerWriteError(ctx, failingWriter{httptest.NewRecorder()}, blaze.ErrorNotFound("missing"), fallback.logger())
+			Expect(fallback.get()).To(BeEmpty())
+			Expect(logs.get()).To(ConsistOf(SatisfyAll(
+				ContainSubstring(`"msg"=""`),
+				ContainSubstring("resp write failed"),
+				ContainSubstring(`"request_id"="req-3"`),
+			)))
+		})
+
+		It("are preferred by ServerEnsurePanicResponses", func() {
+			rec := httptest.NewRecorder()
+			Expect(func() {
+				defer blaze.ServerEnsurePanicResponses(ctx, rec, fallback.logger())
+				panic("boom")
+			}).To(PanicWith("boom"))
+			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+			Expect(fallback.get()).To(BeEmpty())
+			Expect(logs.get()).To(ConsistOf(SatisfyAll(
+				ContainSubstring(`"msg"="Internal service panic"`),
+				ContainSubstring(`"request_id"="req-3"`),
+			)))
+		})
+
+		It("fall back to the given logger", func() {
+			blaze.ServerWriteError(context.Background(), failingWriter{httptest.NewRecorder()}, blaze.ErrorNotFound("missing"), fallback.logger())
+			Expect(fallback.get()).To(ConsistOf(ContainSubstring("resp write failed")))
+		})
+	})
+})
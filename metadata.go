@@ -0,0 +1,130 @@
+package blaze
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// serverMetadata holds the headers of a request to a generated service and the headers and trailers
+// its handler sets on the response
+type serverMetadata struct {
+	mu       sync.Mutex
+	request  http.Header
+	response http.Header
+	trailer  http.Header
+}
+
+type serverMetadataKey struct{}
+
+// WithServerMetadata adds the metadata of a request to the context. Generated services add it
+// before calling the handler and write the response headers with WriteResponseMetadata
+func WithServerMetadata(ctx context.Context, req *http.Request) context.Context {
+	return context.WithValue(ctx, serverMetadataKey{}, &serverMetadata{request: req.Header, response: http.Header{}, trailer: http.Header{}})
+}
+
+func serverMetadataFromContext(ctx context.Context) *serverMetadata {
+	md, _ := ctx.Value(serverMetadataKey{}).(*serverMetadata)
+	return md
+}
+
+// RequestHeaders returns a copy of the headers of the request served by a generated service
+// or nil outside of one
+func RequestHeaders(ctx context.Context) http.Header {
+	md := serverMetadataFromContext(ctx)
+	if md == nil {
+		return nil
+	}
+	return md.request.Clone()
+}
+
+// SetHeader sets a header of the response, successful or not, of a generated service.
+// It has no effect outside of a generated service
+func SetHeader(ctx context.Context, key, value string) {
+	md := serverMetadataFromContext(ctx)
+	if md == nil {
+		return
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.response.Set(key, value)
+}
+
+// SetTrailer sets a trailer of the response, successful or not, of a generated service.
+// Trailers are sent after the body, which is then sent chunked without Content-Length over HTTP/1.1.
+// It has no effect outside of a generated service
+func SetTrailer(ctx context.Context, key, value string) {
+	md := serverMetadataFromContext(ctx)
+	if md == nil {
+		return
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.trailer.Set(key, value)
+}
+
+// cacheHeaders are the response headers set by handlers which only apply to successful responses
+var cacheHeaders = []string{"Cache-Control", "ETag"}
+
+// SetCacheControl sets the Cache-Control header of the response, e.g. "max-age=60".
+// Error responses are sent without it. It has no effect outside of a generated service
+func SetCacheControl(ctx context.Context, value string) {
+	SetHeader(ctx, "Cache-Control", value)
+}
+
+// SetETag sets the ETag header of the response. Unquoted tags are quoted. Error responses are sent without it.
+// GET requests with a matching If-None-Match header are answered with 304 Not Modified.
+// It has no effect outside of a generated service
+func SetETag(ctx context.Context, etag string) {
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	SetHeader(ctx, "ETag", etag)
+}
+
+// WriteResponseMetadata copies the response headers and trailers set by the handler to the response.
+// Trailers are written with http.TrailerPrefix, so it has to be called before the status is written
+func WriteResponseMetadata(ctx context.Context, resp http.ResponseWriter) {
+	md := serverMetadataFromContext(ctx)
+	if md == nil {
+		return
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	for k, v := range md.response {
+		resp.Header()[k] = v
+	}
+	for k, v := range md.trailer {
+		resp.Header()[http.TrailerPrefix+k] = v
+	}
+}
+
+// SetContentLength sets the Content-Length header of the response unless it carries trailers,
+// which are only sent after a body of unknown length
+func SetContentLength(resp http.ResponseWriter, length int) {
+	for k := range resp.Header() {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			return
+		}
+	}
+	resp.Header().Set("Content-Length", strconv.Itoa(length))
+}
+
+// ServerNotModified answers a GET request with 304 Not Modified if its If-None-Match header
+// matches the ETag of the response. It reports whether the response was written
+func ServerNotModified(resp http.ResponseWriter, req *http.Request) bool {
+	etag := resp.Header().Get("ETag")
+	if req.Method != http.MethodGet || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			resp.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package blaze_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+var _ = Describe("Metadata", func() {
+	It("is ignored outside of a generated service", func() {
+		ctx := context.Background()
+		blaze.SetHeader(ctx, "X-Test", "1")
+		blaze.SetTrailer(ctx, "X-Test", "1")
+		Expect(blaze.RequestHeaders(ctx)).To(BeNil())
+		rec := httptest.NewRecorder()
+		blaze.WriteResponseMetadata(ctx, rec)
+		Expect(rec.Header()).To(BeEmpty())
+	})
+
+	It("sends headers and trailers", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			ctx := blaze.WithServerMetadata(req.Context(), req)
+			blaze.SetHeader(ctx, "X-Request", blaze.RequestHeaders(ctx).Get("X-Request"))
+			blaze.SetTrailer(ctx, "X-Checksum", "abc")
+			blaze.WriteResponseMetadata(ctx, resp)
+			blaze.SetContentLength(resp, 2)
+			resp.WriteHeader(http.StatusOK)
+			_, _ = resp.Write([]byte("ok"))
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest("POST", srv.URL, nil)
+		req.Header.Set("X-Request", "1")
+		resp, err := srv.Client().Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("ok"))
+		Expect(resp.Header.Get("X-Request")).To(Equal("1"))
+		Expect(resp.Trailer.Get("X-Checksum")).To(Equal("abc"))
+	})
+
+	It("sets Content-Length without trailers", func() {
+		rec := httptest.NewRecorder()
+		blaze.SetContentLength(rec, 2)
+		Expect(rec.Header().Get("Content-Length")).To(Equal("2"))
+	})
+})
@@ -0,0 +1,46 @@
+package blaze
+
+import "context"
+
+// IdempotencyLevel mirrors the idempotency_level method option of the proto definition
+type IdempotencyLevel int
+
+const (
+	// IdempotencyUnknown marks methods which may have side effects
+	IdempotencyUnknown IdempotencyLevel = iota
+	// NoSideEffects marks methods which do not change any state
+	NoSideEffects
+	// Idempotent marks methods which can be called repeatedly with the same outcome
+	Idempotent
+)
+
+// IsIdempotent returns true if a method with this level may be called repeatedly
+func (l IdempotencyLevel) IsIdempotent() bool {
+	return l == NoSideEffects || l == Idempotent
+}
+
+// MethodInfo describes the method a generated client is calling
+type MethodInfo struct {
+	Service     string
+	Method      string
+	Idempotency IdempotencyLevel
+}
+
+// FullName returns the name of the method in the form Service/Method
+func (m MethodInfo) FullName() string {
+	return m.Service + "/" + m.Method
+}
+
+type methodInfoKey struct{}
+
+// WithMethodInfo adds the description of the called method to the context.
+// It is called by generated clients so HTTPClient implementations can act per method
+func WithMethodInfo(ctx context.Context, info MethodInfo) context.Context {
+	return context.WithValue(ctx, methodInfoKey{}, info)
+}
+
+// MethodInfoFromContext returns the description of the called method and false if there is none
+func MethodInfoFromContext(ctx context.Context) (MethodInfo, bool) {
+	info, ok := ctx.Value(methodInfoKey{}).(MethodInfo)
+	return info, ok
+}
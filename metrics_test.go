@@ -0,0 +1,97 @@
+package blaze_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/blazemetrics"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+// recorded returns the data points of the histogram of the reader by name
+func recorded(reader *sdkmetric.ManualReader, name string) []metricdata.HistogramDataPoint[int64] {
+	var rm metricdata.ResourceMetrics
+	Expect(reader.Collect(context.Background(), &rm)).To(Succeed())
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data.(metricdata.Histogram[int64]).DataPoints
+			}
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Metrics of generated services and clients", func() {
+	var (
+		serverReader, clientReader *sdkmetric.ManualReader
+		srv                        *httptest.Server
+		mountPath                  string
+		client                     health_v1.HealthClient
+	)
+
+	BeforeEach(func() {
+		serverReader = sdkmetric.NewManualReader()
+		clientReader = sdkmetric.NewManualReader()
+		svc := health_v1.NewHealthService(servingHealth{}, logr.Discard(),
+			blaze.WithServiceMetrics(blazemetrics.NewServiceMetrics(blazemetrics.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(serverReader))))),
+			blaze.WithCompressionThreshold(1))
+		mountPath = svc.MountPath()
+		mux := chi.NewMux()
+		mux.Mount(mountPath, svc.Mux())
+		srv = httptest.NewServer(mux)
+		var ok bool
+		client, ok = health_v1.NewHealthClient(srv.URL, srv.Client(),
+			blaze.WithClientMetrics(blazemetrics.NewClientMetrics(blazemetrics.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(clientReader))))),
+			blaze.WithCodec(blaze.NewJSONCodec()),
+			blaze.WithCompression("gzip"), blaze.WithClientCompressionThreshold(1)).(health_v1.HealthClient)
+		Expect(ok).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("measure the same uncompressed message sizes and codec on both sides", func() {
+		in := &grpc_health_v1.HealthCheckRequest{Service: strings.Repeat("service", 100)}
+		out, err := client.Check(context.Background(), in)
+		Expect(err).ToNot(HaveOccurred())
+		reqBytes, err := blaze.NewJSONCodec().Marshal(in)
+		Expect(err).ToNot(HaveOccurred())
+		respBytes, err := blaze.NewJSONCodec().Marshal(out)
+		Expect(err).ToNot(HaveOccurred())
+
+		for side, reader := range map[string]*sdkmetric.ManualReader{"rpc.server": serverReader, "rpc.client": clientReader} {
+			requestSize := recorded(reader, side+".request.size")
+			Expect(requestSize).To(HaveLen(1), side)
+			Expect(requestSize[0].Sum).To(BeEquivalentTo(len(reqBytes)), side)
+			contentType, ok := requestSize[0].Attributes.Value(blazemetrics.ContentTypeKey)
+			Expect(ok).To(BeTrue(), side)
+			Expect(contentType.AsString()).To(Equal(blazemetrics.ContentTypeJSON), side)
+			responseSize := recorded(reader, side+".response.size")
+			Expect(responseSize).To(HaveLen(1), side)
+			Expect(responseSize[0].Sum).To(BeEquivalentTo(len(respBytes)), side)
+		}
+	})
+
+	It("label calls failing before their codec is known as other", func() {
+		resp, err := srv.Client().Post(srv.URL+mountPath+"/Check", "text/plain", strings.NewReader("{}"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+		requestSize := recorded(serverReader, "rpc.server.request.size")
+		Expect(requestSize).To(HaveLen(1))
+		Expect(requestSize[0].Sum).To(BeZero())
+		contentType, _ := requestSize[0].Attributes.Value(blazemetrics.ContentTypeKey)
+		Expect(contentType.AsString()).To(Equal(blazemetrics.ContentTypeOther))
+	})
+})
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"code.cestus.io/blaze"
+)
+
+// APIKeyHeader is the default header carrying API keys
+const APIKeyHeader = "X-Api-Key"
+
+// APIKey is a static key and the principal it authenticates
+type APIKey struct {
+	Key       string
+	Principal Principal
+}
+
+// APIKeyOption is a functional option for extending an API key authenticator
+type APIKeyOption func(*apiKeyAuthenticator)
+
+// WithAPIKeyHeader reads the keys from the header instead of APIKeyHeader
+func WithAPIKeyHeader(header string) APIKeyOption {
+	return func(a *apiKeyAuthenticator) {
+		a.header = header
+	}
+}
+
+type apiKeyAuthenticator struct {
+	header string
+	keys   []apiKeyEntry
+}
+
+type apiKeyEntry struct {
+	digest    [sha256.Size]byte
+	principal Principal
+}
+
+// NewAPIKeyAuthenticator authenticates requests carrying one of the static keys in the X-Api-Key header.
+// Keys are compared in constant time
+func NewAPIKeyAuthenticator(keys []APIKey, opts ...APIKeyOption) Authenticator {
+	a := &apiKeyAuthenticator{header: APIKeyHeader}
+	for _, o := range opts {
+		o(a)
+	}
+	for _, k := range keys {
+		a.keys = append(a.keys, apiKeyEntry{digest: sha256.Sum256([]byte(k.Key)), principal: k.Principal})
+	}
+	return a
+}
+
+// Authenticate looks up the key of the request
+func (a *apiKeyAuthenticator) Authenticate(req *http.Request) (*Principal, error) {
+	key := req.Header.Get(a.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	digest := sha256.Sum256([]byte(key))
+	var found *apiKeyEntry
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], a.keys[i].digest[:]) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found == nil {
+		return nil, blaze.ErrorUnauthenticated("invalid API key")
+	}
+	p := found.principal
+	p.Authenticator = "apikey"
+	return &p, nil
+}
@@ -0,0 +1,145 @@
+// Package auth authenticates the callers of blaze services.
+//
+// Authenticators verify the credentials of a request, e.g. a bearer JWT, a static API key or the
+// client certificate of a mTLS connection, and the Middleware stores the resulting Principal in the
+// context of the request. Requests with missing or invalid credentials are answered with an
+// unauthenticated error. The Policy middleware enforces the scopes and roles the methods of a
+// service declare with the blaze.options.v1.method option.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"code.cestus.io/blaze"
+)
+
+// ErrNoCredentials is returned by authenticators if a request carries none of their credentials
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller e.g. the sub claim of a token, the name of an API key or the name of a certificate
+	Subject string
+	// Authenticator names the authenticator which verified the caller e.g. jwt, apikey or mtls
+	Authenticator string
+	// Scopes granted to the caller
+	Scopes []string
+	// Roles of the caller
+	Roles []string
+	// Claims holds the verified claims of a token
+	Claims map[string]interface{}
+}
+
+// HasScope reports whether the principal was granted the scope
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && contains(p.Scopes, scope)
+}
+
+// HasRole reports whether the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && contains(p.Roles, role)
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal adds the principal to the context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal of the context and false if the caller is not authenticated
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticator verifies the credentials of a request
+type Authenticator interface {
+	// Authenticate returns the principal of the request. It returns ErrNoCredentials if the request
+	// carries none of its credentials and an error, preferably a blaze error, if they are invalid
+	Authenticate(req *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(req *http.Request) (*Principal, error)
+
+// Authenticate calls f(req)
+func (f AuthenticatorFunc) Authenticate(req *http.Request) (*Principal, error) {
+	return f(req)
+}
+
+// Option is a functional option for extending the Middleware
+type Option func(*options)
+
+type options struct {
+	allowAnonymous bool
+}
+
+// AllowAnonymous passes requests without credentials to the service without a principal.
+// Requests with invalid credentials are still rejected
+func AllowAnonymous() Option {
+	return func(o *options) {
+		o.allowAnonymous = true
+	}
+}
+
+// Middleware authenticates requests with the first of the authenticators finding credentials and adds
+// the principal to the context. Requests without credentials or with invalid ones are answered with an
+// unauthenticated error written by blaze.ServerWriteError
+func Middleware(authenticators []Authenticator, opts ...Option) func(http.Handler) http.Handler {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			for _, a := range authenticators {
+				p, err := a.Authenticate(req)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					writeUnauthenticated(resp, req, err)
+					return
+				}
+				next.ServeHTTP(resp, req.WithContext(WithPrincipal(req.Context(), p)))
+				return
+			}
+			if o.allowAnonymous {
+				next.ServeHTTP(resp, req)
+				return
+			}
+			writeUnauthenticated(resp, req, blaze.ErrorUnauthenticated("the request carries no credentials"))
+		})
+	}
+}
+
+// writeUnauthenticated writes err as unauthenticated error encoded with the codec accepted by the caller
+func writeUnauthenticated(resp http.ResponseWriter, req *http.Request, err error) {
+	blerr, ok := err.(blaze.Error)
+	if !ok {
+		blerr = blaze.ErrorUnauthenticated(err.Error())
+	}
+	resp.Header().Set("WWW-Authenticate", "Bearer")
+	writeError(resp, req, blerr)
+}
+
+// writeError writes the error encoded with the codec accepted by the caller
+func writeError(resp http.ResponseWriter, req *http.Request, blerr blaze.Error) {
+	ctx := req.Context()
+	var serviceOptions blaze.ServiceOptions
+	codec, _ := serviceOptions.RequestCodec(req)
+	ctx = blaze.WithResponseCodec(ctx, serviceOptions.ResponseCodec(req.Header.Get("Accept"), codec))
+	blaze.ServerWriteError(ctx, resp, blerr, blaze.LoggerFromContext(ctx))
+}
@@ -0,0 +1,134 @@
+package auth_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze/pkg/auth"
+)
+
+var _ = Describe("APIKeyAuthenticator", func() {
+	a := auth.NewAPIKeyAuthenticator([]auth.APIKey{{Key: "secret", Principal: auth.Principal{Subject: "ci", Roles: []string{"deployer"}}}})
+
+	It("authenticates a known key", func() {
+		req := httptest.NewRequest("POST", "/svc/Method", nil)
+		req.Header.Set(auth.APIKeyHeader, "secret")
+		p, err := a.Authenticate(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Subject).To(Equal("ci"))
+		Expect(p.Authenticator).To(Equal("apikey"))
+		Expect(p.HasRole("deployer")).To(BeTrue())
+	})
+	It("rejects a bad key", func() {
+		req := httptest.NewRequest("POST", "/svc/Method", nil)
+		req.Header.Set(auth.APIKeyHeader, "guess")
+		_, err := a.Authenticate(req)
+		expectUnauthenticated(err, "invalid API key")
+	})
+	It("finds no credentials without key", func() {
+		_, err := a.Authenticate(httptest.NewRequest("POST", "/svc/Method", nil))
+		Expect(err).To(MatchError(auth.ErrNoCredentials))
+	})
+})
+
+var _ = Describe("MTLSAuthenticator", func() {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "worker", OrganizationalUnit: []string{"ops"}},
+		URIs:    []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/worker"}},
+	}
+	request := func(verified bool) *http.Request {
+		req := httptest.NewRequest("POST", "/svc/Method", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return req
+	}
+
+	It("maps a verified certificate", func() {
+		p, err := auth.NewMTLSAuthenticator().Authenticate(request(true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Subject).To(Equal("spiffe://example.org/worker"))
+		Expect(p.Authenticator).To(Equal("mtls"))
+		Expect(p.Roles).To(Equal([]string{"ops"}))
+	})
+	It("ignores an unverified peer certificate", func() {
+		_, err := auth.NewMTLSAuthenticator().Authenticate(request(false))
+		Expect(err).To(MatchError(auth.ErrNoCredentials))
+	})
+	It("rejects certificates the mapper returns no principal for", func() {
+		a := auth.NewMTLSAuthenticator(auth.WithCertificateMapper(func(*x509.Certificate) (*auth.Principal, error) {
+			return nil, nil
+		}))
+		_, err := a.Authenticate(request(true))
+		expectUnauthenticated(err, "not mapped to a principal")
+	})
+})
+
+var _ = Describe("Middleware", func() {
+	var (
+		principal *auth.Principal
+		called    bool
+		handler   http.Handler
+	)
+	authenticators := []auth.Authenticator{
+		auth.NewAPIKeyAuthenticator([]auth.APIKey{{Key: "secret", Principal: auth.Principal{Subject: "ci"}}}),
+	}
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/svc/Method", nil)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	next := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		called = true
+		principal, _ = auth.PrincipalFromContext(req.Context())
+	})
+	BeforeEach(func() {
+		principal, called = nil, false
+		handler = auth.Middleware(authenticators)(next)
+	})
+
+	It("adds the principal to the context", func() {
+		Expect(serve("secret").Code).To(Equal(http.StatusOK))
+		Expect(principal.Subject).To(Equal("ci"))
+	})
+	It("rejects invalid credentials", func() {
+		rec := serve("guess")
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+		Expect(rec.Body.String()).To(ContainSubstring("invalid API key"))
+		Expect(called).To(BeFalse())
+	})
+	It("rejects requests without credentials", func() {
+		rec := serve("")
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(called).To(BeFalse())
+	})
+
+	Context("AllowAnonymous", func() {
+		BeforeEach(func() {
+			handler = auth.Middleware(authenticators, auth.AllowAnonymous())(next)
+		})
+		It("passes requests without credentials without principal", func() {
+			Expect(serve("").Code).To(Equal(http.StatusOK))
+			Expect(called).To(BeTrue())
+			Expect(principal).To(BeNil())
+		})
+		It("still rejects invalid credentials", func() {
+			Expect(serve("guess").Code).To(Equal(http.StatusUnauthorized))
+			Expect(called).To(BeFalse())
+		})
+	})
+})
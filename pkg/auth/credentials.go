@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"code.cestus.io/blaze"
+)
+
+// TokenSource returns the current token of a client, e.g. refreshing it before it expires
+type TokenSource func(ctx context.Context) (string, error)
+
+type bearerCredentials struct {
+	source TokenSource
+}
+
+// BearerToken returns credentials attaching the token as bearer Authorization header.
+// Use it with blaze.WithCredentials
+func BearerToken(token string) blaze.Credentials {
+	return bearerCredentials{source: func(context.Context) (string, error) { return token, nil }}
+}
+
+// BearerTokenSource returns credentials attaching the token of the source as bearer Authorization
+// header. The source is asked for every call. Use it with blaze.WithCredentials
+func BearerTokenSource(source TokenSource) blaze.Credentials {
+	return bearerCredentials{source: source}
+}
+
+// Headers returns the Authorization header
+func (c bearerCredentials) Headers(ctx context.Context) (http.Header, error) {
+	token, err := c.source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return http.Header{"Authorization": {"Bearer " + token}}, nil
+}
+
+type apiKeyCredentials struct {
+	header string
+	key    string
+}
+
+// APIKeyCredentials returns credentials attaching the key in the X-Api-Key header. Use it with blaze.WithCredentials
+func APIKeyCredentials(key string) blaze.Credentials {
+	return apiKeyCredentials{header: APIKeyHeader, key: key}
+}
+
+// Headers returns the API key header
+func (c apiKeyCredentials) Headers(context.Context) (http.Header, error) {
+	return http.Header{http.CanonicalHeaderKey(c.header): {c.key}}, nil
+}
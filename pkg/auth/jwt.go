@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for RS256, PS256 and ES256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cestus.io/blaze"
+)
+
+// JWTOption is a functional option for extending a JWT authenticator
+type JWTOption func(*jwtAuthenticator)
+
+// WithIssuer only accepts tokens with the iss claim
+func WithIssuer(issuer string) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience only accepts tokens with the audience in the aud claim
+func WithAudience(audience string) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithLeeway tolerates the clock skew when validating exp and nbf (default 1 minute)
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// WithScopeClaim sets the claim holding the scopes as space separated string or array (default scope)
+func WithScopeClaim(claim string) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.scopeClaim = claim
+	}
+}
+
+// WithRoleClaim sets the claim holding the roles as space separated string or array (default roles)
+func WithRoleClaim(claim string) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.roleClaim = claim
+	}
+}
+
+// WithoutRequiredExpiration accepts tokens without exp claim, which are valid forever.
+// By default tokens have to expire
+func WithoutRequiredExpiration() JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.expirationOptional = true
+	}
+}
+
+// WithJWKSRefreshInterval sets how often the JWKS file is checked for changes (default 1 minute)
+func WithJWKSRefreshInterval(interval time.Duration) JWTOption {
+	return func(a *jwtAuthenticator) {
+		a.refreshInterval = interval
+	}
+}
+
+type jwtAuthenticator struct {
+	jwks            *jwksFile
+	issuer          string
+	audience        string
+	leeway          time.Duration
+	scopeClaim      string
+	roleClaim       string
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	expirationOptional bool
+}
+
+// NewJWTAuthenticator verifies bearer tokens of the Authorization header against the public keys of
+// a local JWKS file, which is re-read when it changes. RS, PS, ES and EdDSA signatures are supported.
+// Tokens need an exp claim unless WithoutRequiredExpiration is given. The sub claim becomes the subject of the principal
+func NewJWTAuthenticator(jwksPath string, opts ...JWTOption) (Authenticator, error) {
+	a := &jwtAuthenticator{
+		leeway:          time.Minute,
+		scopeClaim:      "scope",
+		roleClaim:       "roles",
+		refreshInterval: time.Minute,
+		now:             time.Now,
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	jwks, err := newJWKSFile(jwksPath, a.refreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	a.jwks = jwks
+	return a, nil
+}
+
+// Authenticate verifies the bearer token of the request
+func (a *jwtAuthenticator) Authenticate(req *http.Request) (*Principal, error) {
+	token, ok := bearerToken(req)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	claims, err := a.verify(token)
+	if err != nil {
+		return nil, blaze.ErrorUnauthenticated("invalid token: " + err.Error())
+	}
+	sub, _ := claims["sub"].(string)
+	return &Principal{
+		Subject:       sub,
+		Authenticator: "jwt",
+		Scopes:        stringsClaim(claims[a.scopeClaim]),
+		Roles:         stringsClaim(claims[a.roleClaim]),
+		Claims:        claims,
+	}, nil
+}
+
+// bearerToken returns the token of a bearer Authorization header
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+type tokenError string
+
+func (e tokenError) Error() string { return string(e) }
+
+// verify checks the signature and the registered claims of a compact JWS and returns its claims
+func (a *jwtAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, tokenError("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, tokenError("malformed header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, tokenError("malformed signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range a.jwks.current() {
+		if (header.Kid != "" && k.kid != header.Kid) || (k.alg != "" && k.alg != header.Alg) {
+			continue
+		}
+		if verifySignature(header.Alg, k.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, tokenError("signature verification failed")
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, tokenError("malformed claims")
+	}
+	return claims, a.validate(claims)
+}
+
+// validate checks the exp, nbf, iss and aud claims
+func (a *jwtAuthenticator) validate(claims map[string]interface{}) error {
+	now := a.now()
+	exp, ok := numericClaim(claims["exp"])
+	switch {
+	case !ok && claims["exp"] != nil:
+		return tokenError("invalid exp claim")
+	case !ok && !a.expirationOptional:
+		return tokenError("token has no exp claim")
+	case ok && now.After(exp.Add(a.leeway)):
+		return tokenError("token is expired")
+	}
+	nbf, ok := numericClaim(claims["nbf"])
+	switch {
+	case !ok && claims["nbf"] != nil:
+		return tokenError("invalid nbf claim")
+	case ok && now.Add(a.leeway).Before(nbf):
+		return tokenError("token is not valid yet")
+	}
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return tokenError("unexpected issuer")
+		}
+	}
+	if a.audience != "" && !contains(audienceClaim(claims["aud"]), a.audience) {
+		return tokenError("unexpected audience")
+	}
+	return nil
+}
+
+// audienceClaim reads the aud claim, which is a single audience if it is a string or a list of audiences
+func audienceClaim(v interface{}) []string {
+	if aud, ok := v.(string); ok {
+		return []string{aud}
+	}
+	if _, ok := v.([]interface{}); ok {
+		return stringsClaim(v)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// maxNumericDate is the latest NumericDate accepted in a claim, the end of the year 9999
+const maxNumericDate = 253402300799
+
+// numericClaim reads a NumericDate claim given in seconds since the epoch
+func numericClaim(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil || f < 0 || f > maxNumericDate {
+		return time.Time{}, false
+	}
+	sec := math.Floor(f)
+	return time.Unix(int64(sec), int64((f-sec)*float64(time.Second))), true
+}
+
+// stringsClaim reads a claim given as space separated string or as array of strings
+func stringsClaim(v interface{}) []string {
+	switch c := v.(type) {
+	case string:
+		return strings.Fields(c)
+	case []interface{}:
+		values := make([]string, 0, len(c))
+		for _, e := range c {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// ecdsaCurveBits is the size of the curve each ecdsa algorithm requires
+var ecdsaCurveBits = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+// verifySignature verifies the signature with the key, which has to match the key type of the algorithm
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) bool {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	case "EdDSA":
+		k, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(k, signed, signature)
+	default:
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+		case "PS":
+			return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if k.Curve.Params().BitSize != ecdsaCurveBits[alg] || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
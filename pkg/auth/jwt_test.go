@@ -0,0 +1,269 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/auth"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(kid, alg string, key *rsa.PrivateKey) map[string]string {
+	jwk := map[string]string{"kty": "RSA", "kid": kid, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+	if alg != "" {
+		jwk["alg"] = alg
+	}
+	return jwk
+}
+
+func writeJWKS(path string, keys ...map[string]string) {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(os.WriteFile(path, data, 0o600)).To(Succeed())
+}
+
+// sign creates a compact JWS of the claims signed with the key by the algorithm
+func sign(alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg == "PS256" {
+			sig, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		r, s, e := ecdsa.Sign(rand.Reader, k, digest[:])
+		sig, err = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), e
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case nil:
+	}
+	Expect(err).ToNot(HaveOccurred())
+	return signed + "." + b64(sig)
+}
+
+func bearer(token string) *http.Request {
+	req, _ := http.NewRequest("POST", "http://localhost/svc/Method", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func expectUnauthenticated(err error, msg string) {
+	var target *blaze.UnauthenticatedErrorType
+	ExpectWithOffset(1, errors.As(err, &target)).To(BeTrue(), "%v", err)
+	ExpectWithOffset(1, err.Error()).To(ContainSubstring(msg))
+}
+
+var _ = Describe("JWTAuthenticator", func() {
+	var (
+		rsaKey   *rsa.PrivateKey
+		ecKey    *ecdsa.PrivateKey
+		edKey    ed25519.PrivateKey
+		jwksPath string
+		claims   func() map[string]interface{}
+		a        auth.Authenticator
+	)
+	BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		var edPub ed25519.PublicKey
+		edPub, edKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		jwksPath = filepath.Join(GinkgoT().TempDir(), "jwks.json")
+		writeJWKS(jwksPath,
+			rsaJWK("rsa", "", rsaKey),
+			rsaJWK("rsa-rs256", "RS256", rsaKey),
+			map[string]string{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPub)},
+		)
+		claims = func() map[string]interface{} {
+			return map[string]interface{}{
+				"sub":   "alice",
+				"iss":   "issuer",
+				"aud":   []string{"other", "svc"},
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"scope": "read write",
+				"roles": []string{"admin"},
+			}
+		}
+		a, err = auth.NewJWTAuthenticator(jwksPath, auth.WithIssuer("issuer"), auth.WithAudience("svc"), auth.WithLeeway(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	DescribeTable("accepts good signatures",
+		func(alg, kid string, key func() interface{}) {
+			p, err := a.Authenticate(bearer(sign(alg, kid, key(), claims())))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.Subject).To(Equal("alice"))
+			Expect(p.Authenticator).To(Equal("jwt"))
+			Expect(p.Scopes).To(Equal([]string{"read", "write"}))
+			Expect(p.Roles).To(Equal([]string{"admin"}))
+		},
+		Entry("RS256", "RS256", "rsa", func() interface{} { return rsaKey }),
+		Entry("PS256", "PS256", "rsa", func() interface{} { return rsaKey }),
+		Entry("RS256 with key alg", "RS256", "rsa-rs256", func() interface{} { return rsaKey }),
+		Entry("ES256", "ES256", "ec", func() interface{} { return ecKey }),
+		Entry("EdDSA", "EdDSA", "ed", func() interface{} { return edKey }),
+	)
+
+	It("rejects a wrong kid", func() {
+		_, err := a.Authenticate(bearer(sign("RS256", "unknown", rsaKey, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+	It("rejects alg none", func() {
+		_, err := a.Authenticate(bearer(sign("none", "rsa", nil, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+	It("rejects HS256 signed with the public key", func() {
+		secret := rsaKey.PublicKey.N.Bytes()
+		_, err := a.Authenticate(bearer(sign("HS256", "rsa", secret, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+	It("rejects a header alg differing from the alg of the key", func() {
+		_, err := a.Authenticate(bearer(sign("PS256", "rsa-rs256", rsaKey, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+	It("rejects a header alg not matching the key type", func() {
+		_, err := a.Authenticate(bearer(sign("ES256", "rsa", ecKey, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+	It("rejects a signature of another key", func() {
+		other, _ := rsa.GenerateKey(rand.Reader, 2048)
+		_, err := a.Authenticate(bearer(sign("RS256", "rsa", other, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+	})
+
+	DescribeTable("validates the claims",
+		func(modify func(c map[string]interface{}), msg string) {
+			c := claims()
+			modify(c)
+			_, err := a.Authenticate(bearer(sign("RS256", "rsa", rsaKey, c)))
+			if msg == "" {
+				Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			expectUnauthenticated(err, msg)
+		},
+		Entry("expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }, "token is expired"),
+		Entry("expired within leeway", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() }, ""),
+		Entry("missing exp", func(c map[string]interface{}) { delete(c, "exp") }, "token has no exp claim"),
+		Entry("invalid exp", func(c map[string]interface{}) { c["exp"] = "tomorrow" }, "invalid exp claim"),
+		Entry("not yet valid", func(c map[string]interface{}) { c["nbf"] = time.Now().Add(2 * time.Minute).Unix() }, "token is not valid yet"),
+		Entry("not yet valid within leeway", func(c map[string]interface{}) { c["nbf"] = time.Now().Add(30 * time.Second).Unix() }, ""),
+		Entry("far future nbf", func(c map[string]interface{}) { c["nbf"] = 1e300 }, "invalid nbf claim"),
+		Entry("far future exp", func(c map[string]interface{}) { c["exp"] = 1e300 }, "invalid exp claim"),
+		Entry("wrong iss", func(c map[string]interface{}) { c["iss"] = "evil" }, "unexpected issuer"),
+		Entry("wrong aud", func(c map[string]interface{}) { c["aud"] = []string{"other"} }, "unexpected audience"),
+		Entry("aud string", func(c map[string]interface{}) { c["aud"] = "svc" }, ""),
+		Entry("aud string containing the audience", func(c map[string]interface{}) { c["aud"] = "evil svc" }, "unexpected audience"),
+		Entry("missing aud", func(c map[string]interface{}) { delete(c, "aud") }, "unexpected audience"),
+	)
+
+	It("accepts tokens without exp if not required", func() {
+		a, err := auth.NewJWTAuthenticator(jwksPath, auth.WithoutRequiredExpiration())
+		Expect(err).ToNot(HaveOccurred())
+		c := claims()
+		delete(c, "exp")
+		_, err = a.Authenticate(bearer(sign("RS256", "rsa", rsaKey, c)))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("finds no credentials without bearer token", func() {
+		req, _ := http.NewRequest("POST", "http://localhost/svc/Method", nil)
+		_, err := a.Authenticate(req)
+		Expect(err).To(MatchError(auth.ErrNoCredentials))
+		req.Header.Set("Authorization", "Basic dXNlcjpwdw==")
+		_, err = a.Authenticate(req)
+		Expect(err).To(MatchError(auth.ErrNoCredentials))
+	})
+
+	It("reloads the JWKS file when it changes", func() {
+		a, err := auth.NewJWTAuthenticator(jwksPath, auth.WithJWKSRefreshInterval(0))
+		Expect(err).ToNot(HaveOccurred())
+		rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+		token := sign("RS256", "rotated", rotated, claims())
+		_, err = a.Authenticate(bearer(token))
+		expectUnauthenticated(err, "signature verification failed")
+
+		writeJWKS(jwksPath, rsaJWK("rotated", "", rotated))
+		_, err = a.Authenticate(bearer(token))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = a.Authenticate(bearer(sign("RS256", "rsa", rsaKey, claims())))
+		expectUnauthenticated(err, "signature verification failed")
+
+		By("keeping the keys if the file becomes invalid")
+		Expect(os.WriteFile(jwksPath, []byte("{"), 0o600)).To(Succeed())
+		_, err = a.Authenticate(bearer(token))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("verifies tokens concurrently while the JWKS file is rewritten", func() {
+		a, err := auth.NewJWTAuthenticator(jwksPath, auth.WithJWKSRefreshInterval(0))
+		Expect(err).ToNot(HaveOccurred())
+		rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+		token := sign("RS256", "rsa", rsaKey, claims())
+		var wg sync.WaitGroup
+		errs := make(chan error, 8*50)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					if _, err := a.Authenticate(bearer(token)); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		for i := 0; i < 20; i++ {
+			// both sets contain the signing key, the order of the keys changes the content
+			if i%2 == 0 {
+				writeJWKS(jwksPath, rsaJWK("rotated", "", rotated), rsaJWK("rsa", "", rsaKey))
+			} else {
+				writeJWKS(jwksPath, rsaJWK("rsa", "", rsaKey), rsaJWK("rotated", "", rotated))
+			}
+		}
+		wg.Wait()
+		close(errs)
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("rejects key sets without signing keys", func() {
+		writeJWKS(jwksPath, map[string]string{"kty": "RSA", "use": "enc", "n": "AQAB", "e": "AQAB"})
+		_, err := auth.NewJWTAuthenticator(jwksPath)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"code.cestus.io/blaze"
+)
+
+// MTLSOption is a functional option for extending a mTLS authenticator
+type MTLSOption func(*mtlsAuthenticator)
+
+// WithCertificateMapper replaces the mapping of the verified client certificate to a principal
+func WithCertificateMapper(mapper func(cert *x509.Certificate) (*Principal, error)) MTLSOption {
+	return func(a *mtlsAuthenticator) {
+		a.mapper = mapper
+	}
+}
+
+type mtlsAuthenticator struct {
+	mapper func(cert *x509.Certificate) (*Principal, error)
+}
+
+// NewMTLSAuthenticator authenticates requests by the client certificate of the connection.
+// Only certificates verified by the tls.Config of the server, e.g. with ClientAuth set to
+// tls.VerifyClientCertIfGiven, are accepted. By default the first URI SAN (e.g. a SPIFFE ID) or
+// else the common name becomes the subject and the organizational units become the roles
+func NewMTLSAuthenticator(opts ...MTLSOption) Authenticator {
+	a := &mtlsAuthenticator{mapper: principalFromCertificate}
+	for _, o := range opts {
+		o(a)
+	}
+	return a
+}
+
+// Authenticate maps the verified client certificate of the request
+func (a *mtlsAuthenticator) Authenticate(req *http.Request) (*Principal, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	p, err := a.mapper(req.TLS.VerifiedChains[0][0])
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, blaze.ErrorUnauthenticated("the client certificate is not mapped to a principal")
+	}
+	p.Authenticator = "mtls"
+	return p, nil
+}
+
+func principalFromCertificate(cert *x509.Certificate) (*Principal, error) {
+	subject := cert.Subject.CommonName
+	if len(cert.URIs) > 0 {
+		subject = cert.URIs[0].String()
+	}
+	return &Principal{Subject: subject, Roles: cert.Subject.OrganizationalUnit}, nil
+}
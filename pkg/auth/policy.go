@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"code.cestus.io/blaze"
+)
+
+const (
+	// MissingScopeMetaKey is the meta key of permission denied errors naming the missing scopes
+	MissingScopeMetaKey = "missing_scope"
+	// RequiredRolesMetaKey is the meta key of permission denied errors naming the roles of which one is required
+	RequiredRolesMetaKey = "required_roles"
+)
+
+// PolicyOption is a functional option for extending the Policy middleware
+type PolicyOption func(*policy)
+
+// DefaultDeny denies calls of methods missing from the authorization table instead of allowing them
+func DefaultDeny() PolicyOption {
+	return func(p *policy) {
+		p.defaultDeny = true
+	}
+}
+
+type policy struct {
+	table       blaze.AuthorizationTable
+	defaultDeny bool
+}
+
+// Policy enforces the generated authorization table of a service, e.g. TesterAuthorization, before the
+// handler is called. Methods are looked up by the path of the request, which may be mounted below a prefix.
+// It has to be used after the Middleware so the principal is known. Calls without a principal are answered with an unauthenticated error unless the method is
+// public, calls lacking a scope or role with a permission denied error naming the requirement in its meta.
+// Methods missing from the table are allowed unless the policy is DefaultDeny
+func Policy(table blaze.AuthorizationTable, opts ...PolicyOption) func(http.Handler) http.Handler {
+	p := &policy{table: table}
+	for _, o := range opts {
+		o(p)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			if err := p.authorize(req); err != nil {
+				writeError(resp, req, err)
+				return
+			}
+			next.ServeHTTP(resp, req)
+		})
+	}
+}
+
+// authorize checks the principal of the request against the requirements of the called method
+func (p *policy) authorize(req *http.Request) blaze.Error {
+	method, requirements, ok := p.lookup(req.URL.Path)
+	if !ok {
+		if p.defaultDeny {
+			return blaze.ErrorPermissionDenied("the method " + req.URL.Path + " has no authorization and is denied by default")
+		}
+		return nil
+	}
+	if requirements.Public {
+		return nil
+	}
+	principal, ok := PrincipalFromContext(req.Context())
+	if !ok {
+		return blaze.ErrorUnauthenticated("the method " + method + " requires authentication")
+	}
+	var missing []string
+	for _, scope := range requirements.Scopes {
+		if !principal.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return blaze.ErrorPermissionDenied("missing scope "+strings.Join(missing, " ")).WithMeta(MissingScopeMetaKey, strings.Join(missing, " "))
+	}
+	if len(requirements.Roles) == 0 {
+		return nil
+	}
+	for _, role := range requirements.Roles {
+		if principal.HasRole(role) {
+			return nil
+		}
+	}
+	roles := strings.Join(requirements.Roles, " ")
+	return blaze.ErrorPermissionDenied("one of the roles "+roles+" is required").WithMeta(RequiredRolesMetaKey, roles)
+}
+
+// lookup returns the requirements of the method served at the path. The path of the method is matched
+// with the whole request path or with its end after any mount prefix
+func (p *policy) lookup(path string) (string, blaze.MethodAuthorization, bool) {
+	for path != "" {
+		if requirements, ok := p.table[path]; ok {
+			return path, requirements, true
+		}
+		next := strings.Index(path[1:], "/")
+		if next < 0 {
+			break
+		}
+		path = path[next+1:]
+	}
+	return "", blaze.MethodAuthorization{}, false
+}
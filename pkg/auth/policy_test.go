@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/auth"
+)
+
+var _ = Describe("Policy", func() {
+	table := blaze.AuthorizationTable{
+		"/reports/v1/Get":    {Scopes: []string{"read", "extra"}},
+		"/reports/v1/Delete": {Roles: []string{"admin", "ops"}},
+		"/reports/v1/Status": {Public: true},
+		"/audit/v1/Get":      {Roles: []string{"auditor"}},
+	}
+	var called bool
+	call := func(path string, principal *auth.Principal, opts ...auth.PolicyOption) (*httptest.ResponseRecorder, map[string]interface{}) {
+		called = false
+		handler := auth.Policy(table, opts...)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			called = true
+		}))
+		req := httptest.NewRequest("POST", path, nil)
+		req.Header.Set("Content-Type", "application/json")
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(context.Background(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		if rec.Code != http.StatusOK {
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		}
+		return rec, body
+	}
+	meta := func(body map[string]interface{}, key string) interface{} {
+		m, _ := body["meta"].(map[string]interface{})
+		return m[key]
+	}
+
+	It("allows principals with all scopes", func() {
+		rec, _ := call("/reports/v1/Get", &auth.Principal{Scopes: []string{"read", "extra"}})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(called).To(BeTrue())
+	})
+	It("denies principals missing a scope", func() {
+		rec, body := call("/reports/v1/Get", &auth.Principal{Scopes: []string{"read"}})
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(meta(body, auth.MissingScopeMetaKey)).To(Equal("extra"))
+		Expect(called).To(BeFalse())
+	})
+	It("allows principals with one of the roles", func() {
+		rec, _ := call("/reports/v1/Delete", &auth.Principal{Roles: []string{"ops"}})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+	It("denies principals without one of the roles", func() {
+		rec, body := call("/reports/v1/Delete", &auth.Principal{Roles: []string{"viewer"}})
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(meta(body, auth.RequiredRolesMetaKey)).To(Equal("admin ops"))
+	})
+	It("allows unauthenticated calls of public methods", func() {
+		rec, _ := call("/reports/v1/Status", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+	It("rejects unauthenticated calls of other methods", func() {
+		rec, _ := call("/reports/v1/Get", nil)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(called).To(BeFalse())
+	})
+	It("tells apart methods of the same name of services sharing a router", func() {
+		rec, _ := call("/audit/v1/Get", &auth.Principal{Scopes: []string{"read", "extra"}})
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		rec, _ = call("/audit/v1/Get", &auth.Principal{Roles: []string{"auditor"}})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+	It("finds methods mounted below a prefix", func() {
+		rec, _ := call("/api/reports/v1/Get", &auth.Principal{Scopes: []string{"read"}})
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		rec, _ = call("/api/reports/v1/Status", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+	It("does not match a method by its name alone", func() {
+		rec, _ := call("/other/v1/Get", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		rec, _ = call("/other/v1/Get", nil, auth.DefaultDeny())
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+	})
+	It("allows methods missing from the table unless DefaultDeny", func() {
+		rec, _ := call("/reports/v1/Echo", &auth.Principal{})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		rec, _ = call("/reports/v1/Echo", &auth.Principal{}, auth.DefaultDeny())
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(called).To(BeFalse())
+	})
+})
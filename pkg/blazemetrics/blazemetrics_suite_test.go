@@ -0,0 +1,13 @@
+package blazemetrics_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBlazemetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Blazemetrics Suite")
+}
@@ -0,0 +1,105 @@
+package blazemetrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The content type label of a call is the name of the codec encoding it.
+const (
+	// ContentTypeJSON is the content type label of calls encoded with the json codec
+	ContentTypeJSON = "json"
+	// ContentTypeProtobuf is the content type label of calls encoded with the protobuf codec
+	ContentTypeProtobuf = "protobuf"
+	// ContentTypeOther is the content type label of calls failing before their codec is known
+	ContentTypeOther = "other"
+)
+
+// Call collects the measurements of a single rpc.
+// The request and response sizes are the sizes of the encoded messages before compression,
+// error responses carry no message and are measured with a size of zero
+type Call struct {
+	mu           sync.Mutex
+	service      string
+	method       string
+	contentType  string
+	start        time.Time
+	requestSize  int64
+	responseSize int64
+	err          error
+}
+
+type callKey struct{}
+
+// WithCall adds the call to the context
+func WithCall(ctx context.Context, call *Call) context.Context {
+	return context.WithValue(ctx, callKey{}, call)
+}
+
+// CallFromContext returns the call of the context or nil. All methods of Call are safe to use on nil
+func CallFromContext(ctx context.Context) *Call {
+	if call, ok := ctx.Value(callKey{}).(*Call); ok {
+		return call
+	}
+	return nil
+}
+
+// SetError records the error the call failed with
+func (c *Call) SetError(err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// SetMethod sets the method of the call
+func (c *Call) SetMethod(method string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.method = method
+}
+
+// defaultMethod sets the method of the call unless the handler set it
+func (c *Call) defaultMethod(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.method == "" {
+		c.method = method
+	}
+}
+
+// SetContentType sets the content type label of the call to the name of its codec
+func (c *Call) SetContentType(contentType string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contentType = contentType
+}
+
+// AddRequestSize adds to the size of the encoded request message before compression
+func (c *Call) AddRequestSize(n int64) {
+	if c == nil || n <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestSize += n
+}
+
+// AddResponseSize adds to the size of the encoded response message before compression
+func (c *Call) AddResponseSize(n int64) {
+	if c == nil || n <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responseSize += n
+}
@@ -0,0 +1,66 @@
+package blazemetrics
+
+import (
+	"context"
+
+	otelcontrib "go.opentelemetry.io/contrib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// TargetKey is the metric attribute for the target URL of a circuit breaker
+	TargetKey = attribute.Key("blaze.target")
+	// CircuitStateKey is the metric attribute for the state a circuit breaker changed to
+	CircuitStateKey = attribute.Key("blaze.circuit_state")
+)
+
+// CircuitBreakerMetrics measures the state of client circuit breakers
+type CircuitBreakerMetrics interface {
+	// StateChanged records the transition of the circuit of target into state
+	StateChanged(ctx context.Context, target string, state string)
+	// Rejected records a call which was short circuited because the circuit of target is open
+	Rejected(ctx context.Context, target string)
+}
+
+type circuitBreakerMetrics struct {
+	transitions metric.Int64Counter
+	rejected    metric.Int64Counter
+}
+
+// NewCircuitBreakerMetrics creates metrics recording blaze.client.circuit_breaker.transitions
+// and blaze.client.circuit_breaker.rejected
+func NewCircuitBreakerMetrics(opts ...MetricsOption) CircuitBreakerMetrics {
+	o := &MetricsOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.mp == nil {
+		o.mp = otel.GetMeterProvider()
+	}
+	meter := o.mp.Meter(meterName, metric.WithInstrumentationVersion(otelcontrib.Version()))
+	m := &circuitBreakerMetrics{}
+	var err error
+	if m.transitions, err = meter.Int64Counter("blaze.client.circuit_breaker.transitions", metric.WithUnit("{transition}"),
+		metric.WithDescription("Counts the state changes of circuit breakers")); err != nil {
+		otel.Handle(err)
+	}
+	if m.rejected, err = meter.Int64Counter("blaze.client.circuit_breaker.rejected", metric.WithUnit("{call}"),
+		metric.WithDescription("Counts the calls rejected by open circuit breakers")); err != nil {
+		otel.Handle(err)
+	}
+	return m
+}
+
+func (m *circuitBreakerMetrics) StateChanged(ctx context.Context, target string, state string) {
+	if m.transitions != nil {
+		m.transitions.Add(ctx, 1, metric.WithAttributes(TargetKey.String(target), CircuitStateKey.String(state)))
+	}
+}
+
+func (m *circuitBreakerMetrics) Rejected(ctx context.Context, target string) {
+	if m.rejected != nil {
+		m.rejected.Add(ctx, 1, metric.WithAttributes(TargetKey.String(target)))
+	}
+}
@@ -0,0 +1,40 @@
+package blazemetrics
+
+import (
+	"context"
+	"time"
+)
+
+// ClientMetrics measures the calls of blaze clients
+type ClientMetrics interface {
+	// StartCall starts measuring a call encoded with the named codec and adds it to the context
+	StartCall(ctx context.Context, service string, method string, contentType string) (context.Context, *Call)
+	// EndCall records the measurements of the call
+	EndCall(ctx context.Context, call *Call)
+}
+
+type clientMetrics struct {
+	in *instruments
+}
+
+// NewClientMetrics creates metrics recording rpc.client.duration, rpc.client.request.size,
+// rpc.client.response.size and rpc.client.calls. The client reports the message sizes of the call (see Call)
+func NewClientMetrics(opts ...MetricsOption) ClientMetrics {
+	return &clientMetrics{
+		in: newInstruments("rpc.client", opts...),
+	}
+}
+
+func (s *clientMetrics) StartCall(ctx context.Context, service string, method string, contentType string) (context.Context, *Call) {
+	call := &Call{
+		service:     service,
+		method:      method,
+		contentType: contentType,
+		start:       time.Now(),
+	}
+	return WithCall(ctx, call), call
+}
+
+func (s *clientMetrics) EndCall(ctx context.Context, call *Call) {
+	s.in.record(ctx, call)
+}
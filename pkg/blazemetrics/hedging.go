@@ -0,0 +1,58 @@
+package blazemetrics
+
+import (
+	"context"
+
+	otelcontrib "go.opentelemetry.io/contrib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// HedgingMetrics measures hedged calls of blaze clients
+type HedgingMetrics interface {
+	// Hedged records a call for which a hedged copy was sent
+	Hedged(ctx context.Context, service string, method string)
+	// HedgeWon records a call which was answered by the hedged copy first
+	HedgeWon(ctx context.Context, service string, method string)
+}
+
+type hedgingMetrics struct {
+	hedges metric.Int64Counter
+	wins   metric.Int64Counter
+}
+
+// NewHedgingMetrics creates metrics recording blaze.client.hedges and blaze.client.hedge.wins
+func NewHedgingMetrics(opts ...MetricsOption) HedgingMetrics {
+	o := &MetricsOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.mp == nil {
+		o.mp = otel.GetMeterProvider()
+	}
+	meter := o.mp.Meter(meterName, metric.WithInstrumentationVersion(otelcontrib.Version()))
+	m := &hedgingMetrics{}
+	var err error
+	if m.hedges, err = meter.Int64Counter("blaze.client.hedges", metric.WithUnit("{call}"),
+		metric.WithDescription("Counts the calls for which a hedged copy was sent")); err != nil {
+		otel.Handle(err)
+	}
+	if m.wins, err = meter.Int64Counter("blaze.client.hedge.wins", metric.WithUnit("{call}"),
+		metric.WithDescription("Counts the calls answered by the hedged copy first")); err != nil {
+		otel.Handle(err)
+	}
+	return m
+}
+
+func (m *hedgingMetrics) Hedged(ctx context.Context, service string, method string) {
+	if m.hedges != nil {
+		m.hedges.Add(ctx, 1, metric.WithAttributes(RPCSystemBlaze, semconv.RPCService(service), semconv.RPCMethod(method)))
+	}
+}
+
+func (m *hedgingMetrics) HedgeWon(ctx context.Context, service string, method string) {
+	if m.wins != nil {
+		m.wins.Add(ctx, 1, metric.WithAttributes(RPCSystemBlaze, semconv.RPCService(service), semconv.RPCMethod(method)))
+	}
+}
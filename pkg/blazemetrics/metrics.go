@@ -0,0 +1,116 @@
+package blazemetrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	otelcontrib "go.opentelemetry.io/contrib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const (
+	meterName = "code.cestus.io/blaze/pkg/blazemetrics"
+
+	// ContentTypeKey is the metric attribute for the content type of a call
+	ContentTypeKey = attribute.Key("blaze.content_type")
+	// ErrorTypeKey is the metric attribute for the blaze error type a call failed with
+	ErrorTypeKey = attribute.Key("blaze.error_type")
+)
+
+// RPCSystemBlaze is the rpc.system attribute of blaze calls
+var RPCSystemBlaze = semconv.RPCSystemKey.String("blaze")
+
+// MetricsOptions encapsulate the configurable parameters of the metrics
+type MetricsOptions struct {
+	mp metric.MeterProvider
+}
+
+// MetricsOption is a functional option for the metrics
+type MetricsOption func(*MetricsOptions)
+
+// WithMeterProvider sets a specific meter provider to be used. If none is set the global provider is used
+func WithMeterProvider(mp metric.MeterProvider) MetricsOption {
+	return func(opts *MetricsOptions) {
+		opts.mp = mp
+	}
+}
+
+type instruments struct {
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+	calls        metric.Int64Counter
+}
+
+// newInstruments creates the instruments for the server or client side (prefix rpc.server or rpc.client)
+func newInstruments(prefix string, opts ...MetricsOption) *instruments {
+	o := &MetricsOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.mp == nil {
+		o.mp = otel.GetMeterProvider()
+	}
+	meter := o.mp.Meter(meterName, metric.WithInstrumentationVersion(otelcontrib.Version()))
+	i := &instruments{}
+	var err error
+	if i.duration, err = meter.Float64Histogram(prefix+".duration", metric.WithUnit("ms"),
+		metric.WithDescription("Measures the duration of blaze calls")); err != nil {
+		otel.Handle(err)
+	}
+	if i.requestSize, err = meter.Int64Histogram(prefix+".request.size", metric.WithUnit("By"),
+		metric.WithDescription("Measures the size of blaze request messages before compression")); err != nil {
+		otel.Handle(err)
+	}
+	if i.responseSize, err = meter.Int64Histogram(prefix+".response.size", metric.WithUnit("By"),
+		metric.WithDescription("Measures the size of blaze response messages before compression")); err != nil {
+		otel.Handle(err)
+	}
+	if i.calls, err = meter.Int64Counter(prefix+".calls", metric.WithUnit("{call}"),
+		metric.WithDescription("Counts the blaze calls")); err != nil {
+		otel.Handle(err)
+	}
+	return i
+}
+
+func (i *instruments) record(ctx context.Context, call *Call) {
+	call.mu.Lock()
+	defer call.mu.Unlock()
+	attrs := []attribute.KeyValue{
+		RPCSystemBlaze,
+		semconv.RPCService(call.service),
+		semconv.RPCMethod(call.method),
+		ContentTypeKey.String(call.contentType),
+	}
+	if call.err != nil {
+		attrs = append(attrs, ErrorTypeKey.String(ErrorType(call.err)))
+	}
+	set := metric.WithAttributes(attrs...)
+	elapsed := float64(time.Since(call.start)) / float64(time.Millisecond)
+	if i.duration != nil {
+		i.duration.Record(ctx, elapsed, set)
+	}
+	if i.requestSize != nil {
+		i.requestSize.Record(ctx, call.requestSize, set)
+	}
+	if i.responseSize != nil {
+		i.responseSize.Record(ctx, call.responseSize, set)
+	}
+	if i.calls != nil {
+		i.calls.Add(ctx, 1, set)
+	}
+}
+
+// ErrorType returns the blaze error type of an error (e.g. *blaze.NotFoundErrorType).
+// Errors which are no blaze errors are reported as unknown
+func ErrorType(err error) string {
+	var typed interface{ Type() string }
+	if errors.As(err, &typed) {
+		return typed.Type()
+	}
+	return "unknown"
+}
@@ -0,0 +1,207 @@
+package blazemetrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"code.cestus.io/blaze/pkg/blazemetrics"
+)
+
+type typedError struct{}
+
+func (typedError) Error() string { return "not found" }
+func (typedError) Type() string  { return "*blaze.NotFoundErrorType" }
+
+// collected reads the metrics of the reader by name
+func collected(reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	Expect(reader.Collect(context.Background(), &rm)).To(Succeed())
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+// calls returns the attributes of the data points of the calls counter and their counts
+func calls(data metricdata.Aggregation) map[attribute.Distinct]int64 {
+	counts := map[attribute.Distinct]int64{}
+	for _, dp := range data.(metricdata.Sum[int64]).DataPoints {
+		counts[dp.Attributes.Equivalent()] = dp.Value
+	}
+	return counts
+}
+
+func attributes(service, method, contentType string, kv ...attribute.KeyValue) attribute.Distinct {
+	set := attribute.NewSet(append([]attribute.KeyValue{
+		attribute.String("rpc.system", "blaze"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+		blazemetrics.ContentTypeKey.String(contentType),
+	}, kv...)...)
+	return set.Equivalent()
+}
+
+func histogramSum(data metricdata.Aggregation) int64 {
+	var sum int64
+	for _, dp := range data.(metricdata.Histogram[int64]).DataPoints {
+		sum += dp.Sum
+	}
+	return sum
+}
+
+var _ = Describe("ServiceMetrics", func() {
+	var (
+		reader *sdkmetric.ManualReader
+		router chi.Router
+	)
+
+	BeforeEach(func() {
+		reader = sdkmetric.NewManualReader()
+		metrics := blazemetrics.NewServiceMetrics(blazemetrics.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		router = chi.NewRouter()
+		router.Use(metrics.Middleware("Svc"))
+	})
+
+	post := func(path, contentType, body string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	It("records calls with the message sizes reported by the handler", func() {
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {
+			call := blazemetrics.CallFromContext(r.Context())
+			call.SetContentType(blazemetrics.ContentTypeJSON)
+			_, _ = io.ReadAll(r.Body)
+			call.AddRequestSize(int64(len("message")))
+			call.AddResponseSize(int64(len("message")))
+			_, _ = w.Write([]byte("compressed message"))
+		})
+		post("/Method", "application/json", "compressed message")
+		post("/Method", "application/json", "compressed message")
+		metrics := collected(reader)
+		Expect(calls(metrics["rpc.server.calls"])).To(Equal(map[attribute.Distinct]int64{
+			attributes("Svc", "Method", blazemetrics.ContentTypeJSON): 2,
+		}))
+		Expect(histogramSum(metrics["rpc.server.request.size"])).To(BeEquivalentTo(2 * len("message")))
+		Expect(histogramSum(metrics["rpc.server.response.size"])).To(BeEquivalentTo(2 * len("message")))
+		Expect(metrics).To(HaveKey("rpc.server.duration"))
+	})
+
+	It("labels calls whose handler sets no codec as other regardless of the header", func() {
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {})
+		post("/Method", "application/json", "")
+		Expect(calls(collected(reader)["rpc.server.calls"])).To(Equal(map[attribute.Distinct]int64{
+			attributes("Svc", "Method", blazemetrics.ContentTypeOther): 1,
+		}))
+	})
+
+	It("records the error type and the content type set by the handler", func() {
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {
+			call := blazemetrics.CallFromContext(r.Context())
+			call.SetContentType(blazemetrics.ContentTypeProtobuf)
+			call.SetError(typedError{})
+			w.WriteHeader(http.StatusNotFound)
+		})
+		post("/Method", "application/x-protobuf", "")
+		Expect(calls(collected(reader)["rpc.server.calls"])).To(Equal(map[attribute.Distinct]int64{
+			attributes("Svc", "Method", blazemetrics.ContentTypeProtobuf, blazemetrics.ErrorTypeKey.String(typedError{}.Type())): 1,
+		}))
+	})
+
+	It("prefers the method set by the handler", func() {
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {
+			blazemetrics.CallFromContext(r.Context()).SetMethod("Renamed")
+		})
+		post("/Method", "text/plain", "")
+		Expect(calls(collected(reader)["rpc.server.calls"])).To(HaveKey(attributes("Svc", "Renamed", blazemetrics.ContentTypeOther)))
+	})
+
+	It("reports unmatched routes as other methods", func() {
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {})
+		post("/Unknown", "application/json", "")
+		Expect(calls(collected(reader)["rpc.server.calls"])).To(HaveKey(attributes("Svc", "_OTHER", blazemetrics.ContentTypeOther)))
+	})
+
+	It("records calls whose method is set concurrently", func() {
+		var wg sync.WaitGroup
+		router.Post("/Method", func(w http.ResponseWriter, r *http.Request) {
+			call := blazemetrics.CallFromContext(r.Context())
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				call.SetMethod("Method")
+			}()
+			// gives the goroutine time to set the method without synchronizing with it
+			time.Sleep(10 * time.Millisecond)
+		})
+		post("/Method", "application/json", "")
+		wg.Wait()
+		Expect(calls(collected(reader)["rpc.server.calls"])).To(HaveKey(attributes("Svc", "Method", blazemetrics.ContentTypeOther)))
+	})
+})
+
+var _ = Describe("ClientMetrics", func() {
+	It("records calls with their payload sizes and errors", func() {
+		reader := sdkmetric.NewManualReader()
+		metrics := blazemetrics.NewClientMetrics(blazemetrics.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		ctx, call := metrics.StartCall(context.Background(), "Svc", "Method", blazemetrics.ContentTypeProtobuf)
+		Expect(blazemetrics.CallFromContext(ctx)).To(BeIdenticalTo(call))
+		call.AddRequestSize(3)
+		call.AddResponseSize(5)
+		call.AddResponseSize(-1)
+		call.SetError(errors.New("untyped"))
+		metrics.EndCall(ctx, call)
+		collected := collected(reader)
+		Expect(calls(collected["rpc.client.calls"])).To(Equal(map[attribute.Distinct]int64{
+			attributes("Svc", "Method", blazemetrics.ContentTypeProtobuf, blazemetrics.ErrorTypeKey.String("unknown")): 1,
+		}))
+		Expect(histogramSum(collected["rpc.client.request.size"])).To(BeEquivalentTo(3))
+		Expect(histogramSum(collected["rpc.client.response.size"])).To(BeEquivalentTo(5))
+	})
+})
+
+var _ = Describe("CircuitBreakerMetrics", func() {
+	It("counts state changes and rejected calls", func() {
+		reader := sdkmetric.NewManualReader()
+		metrics := blazemetrics.NewCircuitBreakerMetrics(blazemetrics.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+		metrics.StateChanged(context.Background(), "http://a", "open")
+		metrics.Rejected(context.Background(), "http://a")
+		metrics.Rejected(context.Background(), "http://a")
+		collected := collected(reader)
+		transitions := attribute.NewSet(blazemetrics.TargetKey.String("http://a"), blazemetrics.CircuitStateKey.String("open"))
+		Expect(calls(collected["blaze.client.circuit_breaker.transitions"])).To(Equal(map[attribute.Distinct]int64{transitions.Equivalent(): 1}))
+		rejected := attribute.NewSet(blazemetrics.TargetKey.String("http://a"))
+		Expect(calls(collected["blaze.client.circuit_breaker.rejected"])).To(Equal(map[attribute.Distinct]int64{rejected.Equivalent(): 2}))
+	})
+})
+
+var _ = Describe("Call", func() {
+	It("can be used without a call in the context", func() {
+		call := blazemetrics.CallFromContext(context.Background())
+		Expect(call).To(BeNil())
+		Expect(func() {
+			call.SetError(errors.New("ignored"))
+			call.SetMethod("Method")
+			call.SetContentType(blazemetrics.ContentTypeJSON)
+			call.AddRequestSize(1)
+			call.AddResponseSize(1)
+		}).ToNot(Panic())
+	})
+})
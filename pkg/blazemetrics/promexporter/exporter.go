@@ -0,0 +1,66 @@
+// Package promexporter exposes the blaze metrics in the prometheus exposition format.
+package promexporter
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// ExporterOptions encapsulate the configurable parameters of the exporter
+type ExporterOptions struct {
+	registry *prometheus.Registry
+}
+
+// ExporterOption is a functional option for the exporter
+type ExporterOption func(*ExporterOptions)
+
+// WithRegistry sets the prometheus registry the metrics are registered with.
+// By default a new registry including the go and process collectors is used
+func WithRegistry(registry *prometheus.Registry) ExporterOption {
+	return func(o *ExporterOptions) {
+		o.registry = registry
+	}
+}
+
+// Exporter provides a meter provider whose metrics are served by its handler
+type Exporter struct {
+	provider *sdkmetric.MeterProvider
+	handler  http.Handler
+}
+
+// NewExporter creates a prometheus exporter.
+// Use the MeterProvider with blazemetrics.WithMeterProvider (or set it as global otel meter provider)
+// and serve the Handler e.g. on /metrics of the admin server
+func NewExporter(opts ...ExporterOption) (*Exporter, error) {
+	o := ExporterOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.registry == nil {
+		o.registry = prometheus.NewRegistry()
+		o.registry.MustRegister(prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	}
+	reader, err := otelprometheus.New(otelprometheus.WithRegisterer(o.registry))
+	if err != nil {
+		return nil, err
+	}
+	e := &Exporter{
+		provider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		handler:  promhttp.HandlerFor(o.registry, promhttp.HandlerOpts{}),
+	}
+	return e, nil
+}
+
+// MeterProvider returns the meter provider which feeds the exporter
+func (e *Exporter) MeterProvider() *sdkmetric.MeterProvider {
+	return e.provider
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func (e *Exporter) Handler() http.Handler {
+	return e.handler
+}
@@ -0,0 +1,51 @@
+package promexporter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"code.cestus.io/blaze/pkg/blazemetrics"
+	"code.cestus.io/blaze/pkg/blazemetrics/promexporter"
+)
+
+var _ = Describe("Exporter", func() {
+	scrape := func(e *promexporter.Exporter) string {
+		rec := httptest.NewRecorder()
+		e.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		return rec.Body.String()
+	}
+
+	It("serves the blaze metrics", func() {
+		e, err := promexporter.NewExporter()
+		Expect(err).ToNot(HaveOccurred())
+		metrics := blazemetrics.NewClientMetrics(blazemetrics.WithMeterProvider(e.MeterProvider()))
+		ctx, call := metrics.StartCall(context.Background(), "Svc", "Method", blazemetrics.ContentTypeJSON)
+		metrics.EndCall(ctx, call)
+		body := scrape(e)
+		Expect(body).To(MatchRegexp(`rpc_client_calls_total\{.*rpc_method="Method".*rpc_service="Svc".*\} 1`))
+		Expect(body).To(ContainSubstring("rpc_client_duration_milliseconds_bucket"))
+		Expect(body).To(ContainSubstring("go_goroutines"))
+	})
+
+	It("registers with the given registry", func() {
+		registry := prometheus.NewRegistry()
+		e, err := promexporter.NewExporter(promexporter.WithRegistry(registry))
+		Expect(err).ToNot(HaveOccurred())
+		metrics := blazemetrics.NewCircuitBreakerMetrics(blazemetrics.WithMeterProvider(e.MeterProvider()))
+		metrics.Rejected(context.Background(), "http://a")
+		families, err := registry.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, f := range families {
+			names = append(names, f.GetName())
+		}
+		Expect(names).To(ContainElement("blaze_client_circuit_breaker_rejected_total"))
+		Expect(scrape(e)).ToNot(ContainSubstring("go_goroutines"))
+	})
+})
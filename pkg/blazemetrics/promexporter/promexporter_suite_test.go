@@ -0,0 +1,13 @@
+package promexporter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPromexporter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Promexporter Suite")
+}
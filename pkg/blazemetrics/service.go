@@ -0,0 +1,59 @@
+package blazemetrics
+
+import (
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ServiceMetrics measures the calls of blaze services
+type ServiceMetrics interface {
+	// Middleware instantiates the metrics middleware of a service
+	Middleware(service string) func(next http.Handler) http.Handler
+}
+
+type serviceMetrics struct {
+	in *instruments
+}
+
+// NewServiceMetrics creates metrics recording rpc.server.duration, rpc.server.request.size,
+// rpc.server.response.size and rpc.server.calls. The middleware measures the duration,
+// the service reports the codec and the message sizes of the call (see Call)
+func NewServiceMetrics(opts ...MetricsOption) ServiceMetrics {
+	return &serviceMetrics{
+		in: newInstruments("rpc.server", opts...),
+	}
+}
+
+func (s *serviceMetrics) Middleware(service string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			call := &Call{
+				service:     service,
+				contentType: ContentTypeOther,
+				start:       time.Now(),
+			}
+			ctx := WithCall(req.Context(), call)
+			defer func() {
+				call.defaultMethod(methodFromRoute(req))
+				s.in.record(ctx, call)
+			}()
+			next.ServeHTTP(resp, req.WithContext(ctx))
+		})
+	}
+}
+
+// methodFromRoute derives the method from the matched route pattern. Unmatched routes are reported as _OTHER
+func methodFromRoute(req *http.Request) string {
+	rctx := chi.RouteContext(req.Context())
+	if rctx == nil {
+		return "_OTHER"
+	}
+	method := path.Base(rctx.RoutePattern())
+	if method == "*" || method == "/" || method == "." {
+		return "_OTHER"
+	}
+	return method
+}
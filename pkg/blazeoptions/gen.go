@@ -0,0 +1,4 @@
+// Package blazeoptions contains the custom proto options read by protoc-gen-blaze.
+package blazeoptions
+
+//go:generate protoc -I ../../proto --go_out=module=code.cestus.io/blaze:../.. blaze/options/v1/options.proto
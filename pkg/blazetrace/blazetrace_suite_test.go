@@ -0,0 +1,13 @@
+package blazetrace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBlazetrace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Blazetrace Suite")
+}
@@ -2,6 +2,7 @@ package blazetrace
 
 import (
 	"context"
+	"sync"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
@@ -22,3 +23,31 @@ func GetOtelTracer(ctx context.Context) trace.Tracer {
 	}
 	return otel.GetTracerProvider().Tracer("")
 }
+
+// serverError is the blaze error a request of the tracing middleware failed with
+type serverError struct {
+	mu        sync.Mutex
+	errorType string
+	msg       string
+}
+
+type serverErrorKey struct{}
+
+// RecordError records the type and message of the blaze error the request of the context failed with
+// on the server span of the tracing middleware. It does nothing outside of the middleware
+func RecordError(ctx context.Context, errorType string, msg string) {
+	se, ok := ctx.Value(serverErrorKey{}).(*serverError)
+	if !ok {
+		return
+	}
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.errorType = errorType
+	se.msg = msg
+}
+
+func (se *serverError) get() (string, string, bool) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	return se.errorType, se.msg, se.errorType != ""
+}
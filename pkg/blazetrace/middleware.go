@@ -1,7 +1,10 @@
 package blazetrace
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
 
@@ -11,6 +14,8 @@ import (
 	"go.opentelemetry.io/otel"
 
 	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 
@@ -19,12 +24,15 @@ import (
 
 const (
 	tracerName = "code.cestus.io/blaze/pkg/blazetrace"
+	// ErrorTypeKey is the span attribute for the blaze error type of a failed request
+	ErrorTypeKey = attribute.Key("blaze.error_type")
 )
 
 // config is used to configure the mux middleware.
 type config struct {
 	TracerProvider oteltrace.TracerProvider
 	Propagators    propagation.TextMapPropagator
+	TrustedProxies []netip.Prefix
 }
 
 // Option specifies instrumentation configuration options.
@@ -47,6 +55,15 @@ func WithTracerProvider(provider oteltrace.TracerProvider) Option {
 	}
 }
 
+// WithTrustedProxies makes the middleware take the client address from the X-Forwarded-For
+// header of requests received from the proxies. Without trusted proxies the header is ignored
+// and the client address is the remote address of the request.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(cfg *config) {
+		cfg.TrustedProxies = append(cfg.TrustedProxies, proxies...)
+	}
+}
+
 // Middleware sets up a handler to start tracing the incoming
 // requests.  The service parameter should describe the name of the
 // (virtual) server handling the request.
@@ -68,20 +85,22 @@ func Middleware(service string, opts ...Option) func(next http.Handler) http.Han
 
 	return func(next http.Handler) http.Handler {
 		tw := traceware{
-			service:     service,
-			tracer:      tracer,
-			propagators: cfg.Propagators,
-			handler:     next,
+			service:        service,
+			tracer:         tracer,
+			propagators:    cfg.Propagators,
+			trustedProxies: cfg.TrustedProxies,
+			handler:        next,
 		}
 		return http.HandlerFunc(tw.ServeHTTP)
 	}
 }
 
 type traceware struct {
-	service     string
-	tracer      oteltrace.Tracer
-	propagators propagation.TextMapPropagator
-	handler     http.Handler
+	service        string
+	tracer         oteltrace.Tracer
+	propagators    propagation.TextMapPropagator
+	trustedProxies []netip.Prefix
+	handler        http.Handler
 }
 
 type recordingResponseWriter struct {
@@ -134,27 +153,97 @@ func (tw traceware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := tw.propagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 
 	rctx := chi.RouteContext(r.Context())
-	routeStr := strings.Join(rctx.RoutePatterns, "")
 	spanName := rctx.RoutePath
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(tw.service),
+		semconv.HTTPRequestMethodKey.String(r.Method),
+		semconv.URLPath(r.URL.Path),
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if addr := tw.clientAddress(r); addr != "" {
+		attrs = append(attrs, semconv.ClientAddress(addr))
+	}
 	opts := []oteltrace.SpanStartOption{
-		// those things do not exist anymore Sometimes i have no idea why those dudes at otel make decisions
-		// and it seems the community also does not.
-		// Since i currently have no setup to validate any of those removing it all for now
-		// oteltrace.WithAttributes(netconv.NetAttributesFromHTTPRequest("tcp", r)...),
-		// oteltrace.WithAttributes(semconv.EndUserAttributesFromHTTPRequest(r)...),
-		// oteltrace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(tw.service, spanName, r)...),
-		oteltrace.WithAttributes(MuxRouteKey.String(routeStr)),
-		oteltrace.WithAttributes(semconv.ServiceNameKey.String(tw.service)),
+		oteltrace.WithAttributes(attrs...),
 		oteltrace.WithSpanKind(oteltrace.SpanKindServer),
 	}
 	ctx, span := tw.tracer.Start(ctx, spanName, opts...)
 	defer span.End()
+	// the blaze error of the request is recorded by the handler independent of the response codec
+	se := &serverError{}
+	ctx = context.WithValue(ctx, serverErrorKey{}, se)
 	r2 := r.WithContext(ctx)
 	rrw := getRRW(w)
 	defer putRRW(rrw)
 	tw.handler.ServeHTTP(rrw.writer, r2)
-	// attrs := semconv.HTTPAttributesFromHTTPStatusCode(rrw.status)
-	// spanStatus, spanMessage := semconv.SpanStatusFromHTTPStatusCode(rrw.status)
-	// span.SetAttributes(attrs...)
-	// span.SetStatus(spanStatus, spanMessage)
+
+	// the route is complete after the request was routed by all (sub) routers
+	routeStr := strings.Join(rctx.RoutePatterns, "")
+	status := rrw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	// the route path is only known up front behind a mounting router
+	if spanName == "" {
+		span.SetName(rctx.RoutePattern())
+	}
+	span.SetAttributes(
+		MuxRouteKey.String(routeStr),
+		semconv.HTTPRoute(rctx.RoutePattern()),
+		semconv.HTTPResponseStatusCode(status),
+	)
+	if status >= 400 {
+		if errorType, msg, ok := se.get(); ok {
+			span.SetAttributes(ErrorTypeKey.String(errorType))
+			span.AddEvent("blaze.error", oteltrace.WithAttributes(ErrorTypeKey.String(errorType), attribute.String("blaze.error_message", msg)))
+		}
+	}
+	// client errors are not errors of the server span
+	if status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// clientAddress returns the address of the client. Requests received from trusted proxies are attributed
+// to the last address of the X-Forwarded-For header which was not added by a trusted proxy
+func (tw traceware) clientAddress(r *http.Request) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !tw.trusted(addr) {
+		return addr
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr = hop
+		if !tw.trusted(hop) {
+			break
+		}
+	}
+	return addr
+}
+
+// trusted reports whether the address belongs to a trusted proxy
+func (tw traceware) trusted(addr string) bool {
+	if len(tw.trustedProxies) == 0 {
+		return false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, proxy := range tw.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,170 @@
+package blazetrace_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/blazetrace"
+)
+
+// attributeOf returns the value of the attribute with the key, an invalid value if it is missing
+func attributeOf(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+var _ = Describe("Middleware", func() {
+	var (
+		exporter *tracetest.InMemoryExporter
+		router   chi.Router
+	)
+
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		router = chi.NewRouter()
+		router.Use(blazetrace.Middleware("svc", blazetrace.WithTracerProvider(provider)))
+	})
+
+	span := func() tracetest.SpanStub {
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		return spans[0]
+	}
+
+	serve := func(codec blaze.Codec, err error) tracetest.SpanStub {
+		router.Post("/svc/Method", func(w http.ResponseWriter, r *http.Request) {
+			blaze.ServerWriteError(blaze.WithResponseCodec(r.Context(), codec), w, err, logr.Discard())
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/svc/Method", nil))
+		return span()
+	}
+
+	It("records the request and response attributes on a server span", func() {
+		router.Route("/svc", func(r chi.Router) {
+			r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+		})
+		req := httptest.NewRequest(http.MethodGet, "/svc/42", nil)
+		req.Header.Set("User-Agent", "blaze-test")
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		s := span()
+		Expect(s.Name).To(Equal("/svc/{id}"))
+		Expect(s.SpanKind).To(Equal(trace.SpanKindServer))
+		Expect(s.Status.Code).To(Equal(codes.Unset))
+		Expect(s.Attributes).To(ContainElements(
+			semconv.ServiceNameKey.String("svc"),
+			semconv.HTTPRequestMethodKey.String(http.MethodGet),
+			semconv.URLPath("/svc/42"),
+			semconv.UserAgentOriginal("blaze-test"),
+			semconv.ClientAddress("192.0.2.1"),
+			blazetrace.MuxRouteKey.String("/svc/*/{id}"),
+			semconv.HTTPRoute("/svc/{id}"),
+			semconv.HTTPResponseStatusCode(http.StatusNoContent),
+		))
+		Expect(attributeOf(s.Attributes, blazetrace.ErrorTypeKey).Type()).To(Equal(attribute.INVALID))
+	})
+
+	It("reports responses without status as ok", func() {
+		router.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+		Expect(span().Attributes).To(ContainElement(semconv.HTTPResponseStatusCode(http.StatusOK)))
+	})
+
+	DescribeTable("records the blaze error of the request",
+		func(codec blaze.Codec) {
+			s := serve(codec, blaze.ErrorNotFound("no such thing"))
+			Expect(attributeOf(s.Attributes, blazetrace.ErrorTypeKey).AsString()).To(Equal(blaze.ErrorNotFound("").Type()))
+			Expect(s.Attributes).To(ContainElement(semconv.HTTPResponseStatusCode(http.StatusNotFound)))
+			Expect(s.Status.Code).To(Equal(codes.Unset))
+			Expect(s.Events).To(HaveLen(1))
+			Expect(s.Events[0].Name).To(Equal("blaze.error"))
+			Expect(s.Events[0].Attributes).To(ConsistOf(
+				blazetrace.ErrorTypeKey.String(blaze.ErrorNotFound("").Type()),
+				attribute.String("blaze.error_message", "no such thing"),
+			))
+		},
+		Entry("json", blaze.NewJSONCodec()),
+		Entry("protobuf", blaze.ProtobufCodec{}),
+	)
+
+	It("marks server faults as errors", func() {
+		s := serve(blaze.ProtobufCodec{}, context.Canceled)
+		Expect(attributeOf(s.Attributes, blazetrace.ErrorTypeKey).AsString()).To(Equal(blaze.ErrorInternal("").Type()))
+		Expect(s.Status.Code).To(Equal(codes.Error))
+		Expect(s.Status.Description).To(Equal(http.StatusText(http.StatusInternalServerError)))
+	})
+
+	It("marks failed responses without blaze error as errors", func() {
+		router.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+		s := span()
+		Expect(s.Status.Code).To(Equal(codes.Error))
+		Expect(attributeOf(s.Attributes, blazetrace.ErrorTypeKey).Type()).To(Equal(attribute.INVALID))
+		Expect(s.Events).To(BeEmpty())
+	})
+
+	It("does not record errors of successful requests", func() {
+		router.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+			blazetrace.RecordError(r.Context(), "ignored", "")
+			w.WriteHeader(http.StatusNoContent)
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+		Expect(attributeOf(span().Attributes, blazetrace.ErrorTypeKey).Type()).To(Equal(attribute.INVALID))
+	})
+
+	It("ignores errors recorded outside of the middleware", func() {
+		Expect(func() { blazetrace.RecordError(context.Background(), "ignored", "") }).ToNot(Panic())
+	})
+})
+
+var _ = DescribeTable("Middleware client address",
+	func(proxies []netip.Prefix, remoteAddr string, forwardedFor []string, address string) {
+		exporter := tracetest.NewInMemoryExporter()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		router := chi.NewRouter()
+		router.Use(blazetrace.Middleware("svc", blazetrace.WithTracerProvider(provider), blazetrace.WithTrustedProxies(proxies...)))
+		router.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+		req.RemoteAddr = remoteAddr
+		for _, fwd := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", fwd)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Attributes).To(ContainElement(semconv.ClientAddress(address)))
+	},
+	Entry("is the remote address without trusted proxies", nil, "192.0.2.1:1234", []string{"198.51.100.7"}, "192.0.2.1"),
+	Entry("is the remote address of untrusted peers", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "192.0.2.1:1234", []string{"198.51.100.7"}, "192.0.2.1"),
+	Entry("is the remote address of trusted proxies without header", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", nil, "10.0.0.1"),
+	Entry("is forwarded by trusted proxies", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"198.51.100.7"}, "198.51.100.7"),
+	Entry("skips the trusted proxies of the chain", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"203.0.113.9, 198.51.100.7", "10.0.0.2"}, "198.51.100.7"),
+	Entry("ignores addresses spoofed by the client", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"10.0.0.3, 198.51.100.7"}, "198.51.100.7"),
+	Entry("is the first address of a fully trusted chain", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"),
+	Entry("trusts ipv4 mapped proxies", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "[::ffff:10.0.0.1]:1234", []string{"198.51.100.7"}, "198.51.100.7"),
+	Entry("trusts ipv6 proxies", []netip.Prefix{netip.MustParsePrefix("2001:db8::/32")}, "[2001:db8::1]:1234", []string{"2001:db8:1::5, 198.51.100.7"}, "198.51.100.7"),
+)
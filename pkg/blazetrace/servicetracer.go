@@ -3,16 +3,31 @@ package blazetrace
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	MuxRouteKey = attribute.Key("mux.routes")
+	// RPCServiceKey is the span attribute for the blaze service name
+	RPCServiceKey = semconv.RPCServiceKey
+	// RPCMethodKey is the span attribute for the blaze method name
+	RPCMethodKey = semconv.RPCMethodKey
+	// RequestSizeKey is the span attribute for the payload size of the request
+	RequestSizeKey = attribute.Key("blaze.request.size")
+	// ResponseSizeKey is the span attribute for the payload size of the response
+	ResponseSizeKey = attribute.Key("blaze.response.size")
+	// DurationKey is the event attribute for the time it took to decode or encode a message in milliseconds
+	DurationKey = attribute.Key("blaze.duration")
 )
 
+// RPCSystemBlaze is the rpc.system attribute of blaze spans
+var RPCSystemBlaze = semconv.RPCSystemKey.String("blaze")
+
 // ServiceTraceOptions
 type ServiceTraceOptions struct {
 	tr  trace.Tracer
@@ -59,7 +74,7 @@ func (s *serverTracer) StartSpan(ctx context.Context, spanName string, attrs []a
 			startOpts = append(startOpts, startOpt)
 		}
 	}
-	startOpts = append(startOpts, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+	startOpts = append(startOpts, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindServer))
 	return s.tr.Start(ctx, spanName, startOpts...)
 }
 
@@ -113,6 +128,28 @@ func WithTracingMiddleware(tmw func(string) func(next http.Handler) http.Handler
 	}
 }
 
+// MessageReceived annotates the span of the context with the size of the decoded request and the time it took to read and decode it
+func MessageReceived(ctx context.Context, size int, decodeDuration time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(RequestSizeKey.Int(size))
+	span.AddEvent("message", trace.WithAttributes(
+		semconv.MessageTypeReceived,
+		semconv.MessageUncompressedSizeKey.Int(size),
+		DurationKey.Float64(float64(decodeDuration)/float64(time.Millisecond)),
+	))
+}
+
+// MessageSent annotates the span of the context with the size of the encoded response and the time it took to encode it
+func MessageSent(ctx context.Context, size int, encodeDuration time.Duration) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(ResponseSizeKey.Int(size))
+	span.AddEvent("message", trace.WithAttributes(
+		semconv.MessageTypeSent,
+		semconv.MessageUncompressedSizeKey.Int(size),
+		DurationKey.Float64(float64(encodeDuration)/float64(time.Millisecond)),
+	))
+}
+
 // WithAttributes collects attributes into a attribute slice
 func WithAttributes(attr ...attribute.KeyValue) []attribute.KeyValue {
 	at := append([]attribute.KeyValue{}, attr...)
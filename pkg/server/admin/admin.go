@@ -0,0 +1,142 @@
+// Package admin provides a server meant to be exposed for operations only.
+//
+// Importing the package registers the handlers of net/http/pprof and expvar on http.DefaultServeMux as
+// a side effect of importing them, services which serve http.DefaultServeMux should only import it
+// if they can expose these handlers.
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cestus.io/blaze/pkg/server"
+	"code.cestus.io/libs/buildinfo"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+)
+
+//ServerBuilder is a Builder for admin servers.
+//An admin server serves
+//	/debug/pprof/  runtime profiles
+//	/debug/vars    expvar variables
+//	/buildinfo     the build information of the binary
+//	/runtime       go runtime information
+//	/routes        the routes of all route sources
+//	/livez,/readyz the health if configured WithHealth
+type ServerBuilder interface {
+	server.BlazeServerBuilder
+	//AddRouteSource adds servers or server builders whose routes are listed on /routes
+	AddRouteSource(src ...server.RouteSource)
+	//Handle adds an additional handler e.g. a prometheus exporter on /metrics
+	Handle(pattern string, handler http.Handler)
+}
+
+//NewServerBuilder creates a new admin server Builder
+func NewServerBuilder(listenAddr string, logger logr.Logger, opts ...server.Option) ServerBuilder {
+	options := server.Options{}
+	for _, o := range opts {
+		o(&options)
+	}
+	sb := &serverBuilder{mux: options.Mux}
+	if sb.mux == nil {
+		sb.mux = chi.NewRouter()
+	}
+	// profiles are taken over the course of seconds, so the default write timeout is too short
+	opts = append([]server.Option{server.WithWriteTimeout(90 * time.Second)}, append(opts, server.WithMux(sb.mux))...)
+	sb.BlazeServerBuilder = server.NewServerBuilder(listenAddr, logger, opts...)
+	sb.Handle("/debug/pprof/*", http.HandlerFunc(pprof.Index))
+	sb.Handle("/debug/pprof/cmdline", http.HandlerFunc(pprof.Cmdline))
+	sb.Handle("/debug/pprof/profile", http.HandlerFunc(pprof.Profile))
+	sb.Handle("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
+	sb.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+	sb.routes = append(sb.routes, func(r chi.Router) {
+		r.Method(http.MethodGet, "/debug/vars", expvar.Handler())
+		r.Get("/buildinfo", serveBuildInfo)
+		r.Get("/runtime", serveRuntime)
+		r.Get("/routes", sb.serveRoutes)
+	})
+	return sb
+}
+
+type serverBuilder struct {
+	server.BlazeServerBuilder
+	mux          *chi.Mux
+	routes       []func(r chi.Router)
+	mu           sync.Mutex
+	routeSources []server.RouteSource
+}
+
+func (s *serverBuilder) AddRouteSource(src ...server.RouteSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routeSources = append(s.routeSources, src...)
+}
+
+func (s *serverBuilder) Handle(pattern string, handler http.Handler) {
+	s.routes = append(s.routes, func(r chi.Router) {
+		r.Handle(pattern, handler)
+	})
+}
+
+// Build creates the server and adds the admin routes after the middlewares were applied to the mux
+func (s *serverBuilder) Build() server.BlazeServer {
+	srv := s.BlazeServerBuilder.Build()
+	for _, route := range s.routes {
+		route(s.mux)
+	}
+	return srv
+}
+
+func serveBuildInfo(resp http.ResponseWriter, req *http.Request) {
+	writeJSON(resp, buildinfo.ProvideBuildInfo())
+}
+
+//RuntimeInfo describes the go runtime of the process
+type RuntimeInfo struct {
+	GoVersion    string `json:"go_version"`
+	GOOS         string `json:"goos"`
+	GOARCH       string `json:"goarch"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	NumGoroutine int    `json:"num_goroutine"`
+}
+
+func serveRuntime(resp http.ResponseWriter, req *http.Request) {
+	writeJSON(resp, RuntimeInfo{
+		GoVersion:    runtime.Version(),
+		GOOS:         runtime.GOOS,
+		GOARCH:       runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+	})
+}
+
+func (s *serverBuilder) serveRoutes(resp http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	sources := append([]server.RouteSource{}, s.routeSources...)
+	s.mu.Unlock()
+	routes := []server.RouteInfo{}
+	for _, src := range sources {
+		routes = append(routes, src.Routes()...)
+	}
+	writeJSON(resp, routes)
+}
+
+func writeJSON(resp http.ResponseWriter, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(buf)
+}
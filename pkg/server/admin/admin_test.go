@@ -0,0 +1,63 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze/pkg/server"
+	"code.cestus.io/blaze/pkg/server/admin"
+)
+
+var _ = Describe("ServerBuilder", func() {
+	var (
+		mux *chi.Mux
+		srv server.BlazeServer
+	)
+	BeforeEach(func() {
+		mux = chi.NewRouter()
+		b := admin.NewServerBuilder("localhost:0", logr.Discard(), server.WithMux(mux))
+		b.AddMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				resp.Header().Set("X-Middleware", "1")
+				next.ServeHTTP(resp, req)
+			})
+		})
+		b.Handle("/metrics", http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {}))
+		srv = b.Build()
+		b.AddRouteSource(srv.(server.RouteSource))
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	It("serves the admin routes behind the middlewares", func() {
+		for _, path := range []string{"/debug/pprof/", "/debug/vars", "/buildinfo", "/runtime", "/metrics"} {
+			rec := get(path)
+			Expect(rec.Code).To(Equal(http.StatusOK), path)
+			Expect(rec.Header().Get("X-Middleware")).To(Equal("1"), path)
+		}
+	})
+
+	It("lists the routes of the route sources", func() {
+		var routes []server.RouteInfo
+		Expect(json.Unmarshal(get("/routes").Body.Bytes(), &routes)).To(Succeed())
+		Expect(routes).To(ContainElements(
+			server.RouteInfo{Method: "GET", Route: "/routes"},
+			server.RouteInfo{Method: "GET", Route: "/debug/pprof/*"},
+		))
+	})
+
+	It("reports the go runtime", func() {
+		var info admin.RuntimeInfo
+		Expect(json.Unmarshal(get("/runtime").Body.Bytes(), &info)).To(Succeed())
+		Expect(info.NumCPU).To(BeNumerically(">", 0))
+	})
+})
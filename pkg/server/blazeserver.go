@@ -22,10 +22,14 @@ type Option func(*Options)
 // Options encapsulate the configurable parameters on a Blaze server.
 type Options struct {
 	// Uses a specific mux instead of chi.NewRouter()
-	Mux          *chi.Mux
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	idleTimeout  time.Duration
+	Mux             *chi.Mux
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	drainPeriod     time.Duration
+	shutdownHooks   []func()
+	health          *Health
 }
 
 // WithMux allows to set the chi mux to use by a server
@@ -56,6 +60,37 @@ func WithIdleTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithShutdownTimeout allows to set the time a server waits for active connections to finish during shutdown
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithDrainPeriod allows to set the time a server keeps serving after shutdown was requested.
+// The pre shutdown hooks are run before the drain period starts, this gives load balancers
+// time to deregister the server after its readiness started failing.
+func WithDrainPeriod(period time.Duration) Option {
+	return func(o *Options) {
+		o.drainPeriod = period
+	}
+}
+
+// WithPreShutdownHook adds a hook which is called when the shutdown of the server starts (before the drain period)
+func WithPreShutdownHook(hook func()) Option {
+	return func(o *Options) {
+		o.shutdownHooks = append(o.shutdownHooks, hook)
+	}
+}
+
+// WithHealth serves the liveness and readiness of the health checks on /livez and /readyz.
+// The readiness fails as soon as the shutdown of the server starts
+func WithHealth(health *Health) Option {
+	return func(o *Options) {
+		o.health = health
+	}
+}
+
 //BlazeServerBuilder is a Builder for blaze servers
 type BlazeServerBuilder interface {
 	//Add Middleware adds one or many middlewares to the server
@@ -99,9 +134,23 @@ func (s *blazeServerBuilder) AddServerMount(svm ...BlazeServiceMount) {
 	s.serverMounts = append(s.serverMounts, svm...)
 }
 
+func (s *blazeServerBuilder) Routes() []RouteInfo {
+	routes, err := walkRoutes(s.serviceOptions.Mux)
+	if err != nil {
+		s.log.Error(err, "Cannot walk route", "addr", s.listenAddr)
+	}
+	return routes
+}
+
 func (s *blazeServerBuilder) Build() BlazeServer {
 	r := s.serviceOptions.Mux
 	r.Use(s.middlewares...)
+	shutdownHooks := s.serviceOptions.shutdownHooks
+	if h := s.serviceOptions.health; h != nil {
+		r.Method(http.MethodGet, "/livez", h.LivenessHandler())
+		r.Method(http.MethodGet, "/readyz", h.ReadinessHandler())
+		shutdownHooks = append([]func(){h.SetShuttingDown}, shutdownHooks...)
+	}
 	for _, bsm := range s.serverMounts {
 		for _, mp := range bsm.Mounts() {
 			r.Mount(mp+bsm.Service().MountPath(), bsm.Service().Mux())
@@ -125,7 +174,18 @@ func (s *blazeServerBuilder) Build() BlazeServer {
 		srv.WriteTimeout = s.serviceOptions.writeTimeout
 	}
 
-	return &blazeServer{s.log, &srv}
+	bs := blazeServer{
+		l:               s.log,
+		Server:          &srv,
+		mux:             r,
+		shutdownTimeout: 30 * time.Second,
+		drainPeriod:     s.serviceOptions.drainPeriod,
+		shutdownHooks:   shutdownHooks,
+	}
+	if s.serviceOptions.shutdownTimeout != 0 {
+		bs.shutdownTimeout = s.serviceOptions.shutdownTimeout
+	}
+	return &bs
 }
 
 //BlazeServiceMount defines a Service and a list of mountpoints e.g "/", "prefix"
@@ -163,49 +223,114 @@ type BlazeServer interface {
 	//Walk prints the registered routes
 	Walk()
 }
+
+//Runner is implemented by servers and server groups which can be run until a context is done.
+//The servers of this package serve until the context is done and then gracefully shut down,
+//Run returns an error if a server could not listen or not be shut down gracefully
+type Runner interface {
+	Run(ctx context.Context) error
+}
 type blazeServer struct {
 	l logr.Logger
 	*http.Server
+	mux             *chi.Mux
+	shutdownTimeout time.Duration
+	drainPeriod     time.Duration
+	shutdownHooks   []func()
 }
 
 func (s *blazeServer) Start(interrupt chan struct{}, wg *sync.WaitGroup) {
 	wg.Add(1)
 	go func(interrupt chan struct{}, wg *sync.WaitGroup) {
 		defer wg.Done()
-		s.l.V(1).Info("Starting server", "addr", s.Addr)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 		go func() {
-			if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				s.l.Error(err, "Could not listen on", "addr", s.Addr)
+			select {
+			case <-interrupt:
+				cancel()
+			case <-ctx.Done():
 			}
 		}()
-		<-interrupt
-		s.gracefullShutdown()
+		if err := s.Run(ctx); err != nil {
+			s.l.Error(err, "Server failed", "addr", s.Addr)
+		}
 	}(interrupt, wg)
 }
 
-func (s *blazeServer) gracefullShutdown() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *blazeServer) Run(ctx context.Context) error {
+	s.l.V(1).Info("Starting server", "addr", s.Addr)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("could not listen on %s: %w", s.Addr, err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	return s.gracefullShutdown()
+}
+
+func (s *blazeServer) gracefullShutdown() error {
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
+	if s.drainPeriod > 0 {
+		s.l.V(1).Info("Draining server", "addr", s.Addr, "period", s.drainPeriod.String())
+		time.Sleep(s.drainPeriod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
 	s.SetKeepAlivesEnabled(false)
 	if err := s.Shutdown(ctx); err != nil {
-		s.l.Error(err, "Could not gracefully shutdown the server")
+		return fmt.Errorf("could not gracefully shutdown the server: %w", err)
 	}
 	s.l.V(1).Info("Server stopped", "addr", s.Addr)
+	return nil
 }
 
 func (s *blazeServer) Walk() {
 	log := s.l.WithValues("addr", s.Addr)
+	for _, route := range s.Routes() {
+		log.V(2).Info("Serving Route", "Route", fmt.Sprintf("%s %s", route.Method, route.Route))
+	}
+}
+
+func (s *blazeServer) Routes() []RouteInfo {
+	routes, err := walkRoutes(s.mux)
+	if err != nil {
+		s.l.Error(err, "Cannot walk route", "addr", s.Addr)
+	}
+	return routes
+}
+
+//RouteInfo describes a route served by a server
+type RouteInfo struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+}
+
+//RouteSource is implemented by servers and server builders which can list their routes.
+//The routes of a builder are complete after Build
+type RouteSource interface {
+	Routes() []RouteInfo
+}
+
+func walkRoutes(r chi.Routes) ([]RouteInfo, error) {
+	var routes []RouteInfo
 	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
 		route = strings.Replace(route, "/*/", "/", -1)
-		log.V(2).Info("Serving Route", "Route", fmt.Sprintf("%s %s", method, route))
+		routes = append(routes, RouteInfo{Method: method, Route: route})
 		return nil
 	}
-	if r, ok := s.Handler.(*chi.Mux); ok {
-		if err := chi.Walk(r, walkFunc); err != nil {
-			log.Error(err, "Cannot walk route")
-		}
-	}
+	err := chi.Walk(r, walkFunc)
+	return routes, err
 }
 
 //NewInterruptNotifier returns a channel wich is closed on a received interrupt
@@ -223,12 +348,31 @@ func NewInterruptNotifier(log logr.Logger) chan struct{} {
 	return f
 }
 
+//NewInterruptContext returns a context which is canceled on a received interrupt
+func NewInterruptContext(parent context.Context, log logr.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(quit)
+		select {
+		case sig := <-quit:
+			log.V(1).Info("Shutting down", "reason", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 //NewTerminatedNotifier creates a channel which can be used to listen to the termination of the server group
 func NewTerminatedNotifier() chan struct{} {
 	return make(chan struct{})
 }
 
-//BlazeServerGroup defines a BlazeServerGroup
+//BlazeServerGroup defines a BlazeServerGroup.
+//The server groups of this package implement Runner, Run runs all servers until the context is done,
+//waits for their termination and returns the first error reported by any server
 type BlazeServerGroup interface {
 	//Start starts all registered servers
 	Start()
@@ -239,20 +383,28 @@ type BlazeServerGroup interface {
 }
 
 type blazeServerGroup struct {
-	interrupt  chan struct{}
-	terminated chan struct{}
-	waitGroup  sync.WaitGroup
-	servers    []BlazeServer
+	interrupt     chan struct{}
+	terminated    chan struct{}
+	terminateOnce sync.Once
+	waitGroup     sync.WaitGroup
+	servers       []BlazeServer
 }
 
 func (s *blazeServerGroup) Start() {
 	for _, srv := range s.servers {
 		srv.Start(s.interrupt, &s.waitGroup)
 	}
-	go func(t chan struct{}) {
+	go func() {
 		s.waitGroup.Wait()
-		close(t)
-	}(s.terminated)
+		s.terminate()
+	}()
+}
+
+// terminate closes the termination notifier once, the group may be started and run repeatedly
+func (s *blazeServerGroup) terminate() {
+	s.terminateOnce.Do(func() {
+		close(s.terminated)
+	})
 }
 
 func (s *blazeServerGroup) GetTerminationNotfier() chan struct{} {
@@ -263,6 +415,52 @@ func (s *blazeServerGroup) Wait() {
 	<-s.GetTerminationNotfier()
 }
 
+func (s *blazeServerGroup) Run(ctx context.Context) error {
+	// a failing server stops the whole group
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, len(s.servers))
+	for _, srv := range s.servers {
+		go func(srv BlazeServer) {
+			err := runServer(ctx, srv)
+			if err != nil {
+				cancel()
+			}
+			errs <- err
+		}(srv)
+	}
+	var firstErr error
+	for range s.servers {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.terminate()
+	return firstErr
+}
+
+// runServer runs servers which do not implement Runner through Start until the context is done
+func runServer(ctx context.Context, srv BlazeServer) error {
+	if r, ok := srv.(Runner); ok {
+		return r.Run(ctx)
+	}
+	interrupt := make(chan struct{})
+	var wg sync.WaitGroup
+	srv.Start(interrupt, &wg)
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-ctx.Done():
+		close(interrupt)
+		<-stopped
+	case <-stopped:
+	}
+	return nil
+}
+
 //NewBlazeServerGroup creates a new server group
 func NewBlazeServerGroup(interrupt chan struct{}, terminated chan struct{}, servers ...BlazeServer) BlazeServerGroup {
 	sg := blazeServerGroup{
@@ -0,0 +1,100 @@
+package server_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze/pkg/server"
+)
+
+// startOnlyServer implements BlazeServer without Runner
+type startOnlyServer struct {
+	stopped chan struct{}
+}
+
+func (s *startOnlyServer) Start(interrupt chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-interrupt
+		close(s.stopped)
+	}()
+}
+
+func (s *startOnlyServer) Walk() {}
+
+var _ = Describe("BlazeServerGroup", func() {
+	newGroup := func(interrupt chan struct{}) server.BlazeServerGroup {
+		b := server.NewServerBuilder("127.0.0.1:0", logr.Discard(), server.WithShutdownTimeout(time.Second))
+		return server.NewServerGroupFromBuilders(interrupt, nil, false, b)
+	}
+
+	It("runs until the context is done and notifies the termination", func() {
+		group := newGroup(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(group.(server.Runner).Run(ctx)).To(Succeed())
+		Expect(group.GetTerminationNotfier()).To(BeClosed())
+	})
+
+	It("can be run again", func() {
+		group := newGroup(nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(group.(server.Runner).Run(ctx)).To(Succeed())
+		Expect(group.(server.Runner).Run(ctx)).To(Succeed())
+	})
+
+	It("can be started and run", func() {
+		interrupt := make(chan struct{})
+		group := newGroup(interrupt)
+		group.Start()
+		close(interrupt)
+		group.Wait()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(group.(server.Runner).Run(ctx)).To(Succeed())
+	})
+
+	It("exposes Run and the routes through optional interfaces", func() {
+		b := server.NewServerBuilder("127.0.0.1:0", logr.Discard())
+		srv := b.Build()
+		_, ok := srv.(server.Runner)
+		Expect(ok).To(BeTrue())
+		builderRoutes, ok := b.(server.RouteSource)
+		Expect(ok).To(BeTrue())
+		serverRoutes, ok := srv.(server.RouteSource)
+		Expect(ok).To(BeTrue())
+		Expect(builderRoutes.Routes()).To(Equal(serverRoutes.Routes()))
+	})
+
+	It("runs servers which do not implement Runner through Start", func() {
+		srv := &startOnlyServer{stopped: make(chan struct{})}
+		group := server.NewBlazeServerGroup(nil, nil, srv)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(group.(server.Runner).Run(ctx)).To(Succeed())
+		Expect(srv.stopped).To(BeClosed())
+		Expect(group.GetTerminationNotfier()).To(BeClosed())
+	})
+
+	It("stops started servers on interrupt", func() {
+		interrupt := make(chan struct{})
+		b := server.NewServerBuilder("127.0.0.1:0", logr.Discard())
+		srv := b.Build()
+		var wg sync.WaitGroup
+		srv.Start(interrupt, &wg)
+		close(interrupt)
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// HealthStatusOK is reported by passing health checks
+	HealthStatusOK = "ok"
+	// HealthStatusFailed is reported by failing health checks
+	HealthStatusFailed = "failed"
+
+	defaultHealthCheckTimeout = 5 * time.Second
+)
+
+// ErrShuttingDown is reported by the readiness of a server which is shutting down
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// HealthCheck checks the health of a component. A returned error marks the component as unhealthy
+type HealthCheck func(ctx context.Context) error
+
+// HealthCheckOption is a functional option for extending a health check.
+type HealthCheckOption func(*healthCheck)
+
+// WithHealthCheckTimeout allows to set the time after which a health check is considered failed
+func WithHealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.timeout = timeout
+	}
+}
+
+type healthCheck struct {
+	name    string
+	check   HealthCheck
+	timeout time.Duration
+}
+
+// HealthCheckResult is the outcome of a single health check
+type HealthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport is the outcome of a liveness or readiness probe
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// Healthy returns true if all checks of the report passed
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthStatusOK
+}
+
+// Health is a registry of named liveness and readiness checks.
+// Liveness checks are part of the readiness as well. Once the server is shutting down readiness fails.
+type Health struct {
+	mu           sync.RWMutex
+	liveness     []healthCheck
+	readiness    []healthCheck
+	shuttingDown atomic.Bool
+}
+
+// NewHealth creates a new health check registry
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddLivenessCheck registers a check which fails the liveness and readiness of the server
+func (h *Health) AddLivenessCheck(name string, check HealthCheck, opts ...HealthCheckOption) {
+	c := newHealthCheck(name, check, opts...)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, c)
+}
+
+// AddReadinessCheck registers a check which fails the readiness of the server
+func (h *Health) AddReadinessCheck(name string, check HealthCheck, opts ...HealthCheckOption) {
+	c := newHealthCheck(name, check, opts...)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, c)
+}
+
+func newHealthCheck(name string, check HealthCheck, opts ...HealthCheckOption) healthCheck {
+	c := healthCheck{
+		name:    name,
+		check:   check,
+		timeout: defaultHealthCheckTimeout,
+	}
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// SetShuttingDown makes the readiness fail. It is called by the server when the graceful shutdown starts
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// ShuttingDown returns true if the server is shutting down
+func (h *Health) ShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
+// Liveness runs all liveness checks
+func (h *Health) Liveness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append([]healthCheck{}, h.liveness...)
+	h.mu.RUnlock()
+	return runHealthChecks(ctx, checks)
+}
+
+// Readiness runs all liveness and readiness checks
+func (h *Health) Readiness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append(append([]healthCheck{}, h.liveness...), h.readiness...)
+	h.mu.RUnlock()
+	report := runHealthChecks(ctx, checks)
+	if h.ShuttingDown() {
+		report.Status = HealthStatusFailed
+		report.Checks = append(report.Checks, HealthCheckResult{
+			Name:     "shutdown",
+			Status:   HealthStatusFailed,
+			Error:    ErrShuttingDown.Error(),
+			Duration: time.Duration(0).String(),
+		})
+	}
+	return report
+}
+
+// Check runs the liveness or readiness check registered with the given name.
+// It returns false if no check with this name is registered
+func (h *Health) Check(ctx context.Context, name string) (HealthCheckResult, bool) {
+	h.mu.RLock()
+	var checks []healthCheck
+	for _, c := range append(append([]healthCheck{}, h.liveness...), h.readiness...) {
+		if c.name == name {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.RUnlock()
+	if len(checks) == 0 {
+		return HealthCheckResult{}, false
+	}
+	return runHealthChecks(ctx, checks[:1]).Checks[0], true
+}
+
+func runHealthChecks(ctx context.Context, checks []healthCheck) HealthReport {
+	report := HealthReport{
+		Status: HealthStatusOK,
+		Checks: make([]HealthCheckResult, len(checks)),
+	}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, r := range report.Checks {
+		if r.Status != HealthStatusOK {
+			report.Status = HealthStatusFailed
+		}
+	}
+	return report
+}
+
+func runHealthCheck(ctx context.Context, c healthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check did not finish: %w", ctx.Err())
+	}
+	res := HealthCheckResult{
+		Name:     c.name,
+		Status:   HealthStatusOK,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		res.Status = HealthStatusFailed
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// LivenessHandler serves the liveness of the server. Check results are listed with the verbose query parameter
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		writeHealthReport(resp, req, h.Liveness(req.Context()))
+	})
+}
+
+// ReadinessHandler serves the readiness of the server. Check results are listed with the verbose query parameter
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		writeHealthReport(resp, req, h.Readiness(req.Context()))
+	})
+}
+
+func writeHealthReport(resp http.ResponseWriter, req *http.Request, report HealthReport) {
+	if _, verbose := req.URL.Query()["verbose"]; !verbose {
+		report.Checks = nil
+	}
+	buf, err := json.Marshal(&report)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	statusCode := http.StatusOK
+	if !report.Healthy() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Cache-Control", "no-store")
+	resp.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	resp.WriteHeader(statusCode)
+	_, _ = resp.Write(buf)
+}
@@ -0,0 +1,165 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze/pkg/server"
+)
+
+var (
+	pass = func(context.Context) error { return nil }
+	fail = func(context.Context) error { return errors.New("broken") }
+)
+
+var _ = Describe("Health", func() {
+	var health *server.Health
+
+	BeforeEach(func() {
+		health = server.NewHealth()
+	})
+
+	It("is healthy without checks", func() {
+		Expect(health.Liveness(context.Background()).Healthy()).To(BeTrue())
+		Expect(health.Readiness(context.Background()).Healthy()).To(BeTrue())
+	})
+
+	It("fails the readiness but not the liveness with a failing readiness check", func() {
+		health.AddLivenessCheck("live", pass)
+		health.AddReadinessCheck("ready", fail)
+		Expect(health.Liveness(context.Background()).Healthy()).To(BeTrue())
+		report := health.Readiness(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks).To(HaveLen(2))
+		Expect(report.Checks[1].Name).To(Equal("ready"))
+		Expect(report.Checks[1].Status).To(Equal(server.HealthStatusFailed))
+		Expect(report.Checks[1].Error).To(Equal("broken"))
+	})
+
+	It("fails the liveness and readiness with a failing liveness check", func() {
+		health.AddLivenessCheck("live", fail)
+		health.AddReadinessCheck("ready", pass)
+		Expect(health.Liveness(context.Background()).Healthy()).To(BeFalse())
+		Expect(health.Readiness(context.Background()).Healthy()).To(BeFalse())
+	})
+
+	It("fails checks which do not finish in time", func() {
+		release := make(chan struct{})
+		defer close(release)
+		health.AddLivenessCheck("slow", func(context.Context) error {
+			<-release
+			return nil
+		}, server.WithHealthCheckTimeout(20*time.Millisecond))
+		start := time.Now()
+		report := health.Liveness(context.Background())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks[0].Error).To(ContainSubstring("did not finish"))
+	})
+
+	It("cancels the context of checks after the timeout", func() {
+		health.AddLivenessCheck("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, server.WithHealthCheckTimeout(20*time.Millisecond))
+		report := health.Liveness(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks[0].Error).To(ContainSubstring(context.DeadlineExceeded.Error()))
+	})
+
+	It("fails checks which panic", func() {
+		health.AddLivenessCheck("panic", func(context.Context) error { panic("boom") })
+		report := health.Liveness(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks[0].Error).To(Equal("panic: boom"))
+	})
+
+	It("fails the readiness once the server is shutting down", func() {
+		health.AddReadinessCheck("ready", pass)
+		health.SetShuttingDown()
+		Expect(health.ShuttingDown()).To(BeTrue())
+		Expect(health.Liveness(context.Background()).Healthy()).To(BeTrue())
+		report := health.Readiness(context.Background())
+		Expect(report.Healthy()).To(BeFalse())
+		Expect(report.Checks).To(ContainElement(HaveField("Error", server.ErrShuttingDown.Error())))
+	})
+
+	It("runs single checks by name", func() {
+		health.AddLivenessCheck("live", pass)
+		health.AddReadinessCheck("ready", fail)
+		res, ok := health.Check(context.Background(), "ready")
+		Expect(ok).To(BeTrue())
+		Expect(res.Status).To(Equal(server.HealthStatusFailed))
+		res, ok = health.Check(context.Background(), "live")
+		Expect(ok).To(BeTrue())
+		Expect(res.Status).To(Equal(server.HealthStatusOK))
+		_, ok = health.Check(context.Background(), "unknown")
+		Expect(ok).To(BeFalse())
+	})
+
+	Context("handlers", func() {
+		get := func(h http.Handler, target string) (*httptest.ResponseRecorder, server.HealthReport) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			var report server.HealthReport
+			Expect(json.Unmarshal(rec.Body.Bytes(), &report)).To(Succeed())
+			return rec, report
+		}
+
+		It("serve the status without the checks", func() {
+			health.AddLivenessCheck("live", pass)
+			rec, report := get(health.LivenessHandler(), "/livez")
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Cache-Control")).To(Equal("no-store"))
+			Expect(report.Status).To(Equal(server.HealthStatusOK))
+			Expect(report.Checks).To(BeEmpty())
+		})
+
+		It("list the checks when verbose", func() {
+			health.AddReadinessCheck("ready", fail)
+			rec, report := get(health.ReadinessHandler(), "/readyz?verbose")
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(report.Checks).To(HaveLen(1))
+			Expect(report.Checks[0].Name).To(Equal("ready"))
+		})
+	})
+
+	It("flips the readiness of a server when its shutdown starts", func() {
+		health.AddReadinessCheck("ready", pass)
+		mux := chi.NewMux()
+		draining := make(chan struct{})
+		srv := server.NewServerBuilder("127.0.0.1:0", logr.Discard(),
+			server.WithMux(mux),
+			server.WithHealth(health),
+			server.WithDrainPeriod(200*time.Millisecond),
+			server.WithPreShutdownHook(func() { close(draining) }),
+		).Build()
+		probe := httptest.NewServer(mux)
+		defer probe.Close()
+		status := func(path string) int {
+			resp, err := probe.Client().Get(probe.URL + path)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		Expect(status("/readyz")).To(Equal(http.StatusOK))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error, 1)
+		go func() { stopped <- srv.(server.Runner).Run(ctx) }()
+		cancel()
+		Eventually(draining).Should(BeClosed())
+		Expect(status("/readyz")).To(Equal(http.StatusServiceUnavailable))
+		Expect(status("/livez")).To(Equal(http.StatusOK))
+		Eventually(stopped).Should(Receive(BeNil()))
+	})
+})
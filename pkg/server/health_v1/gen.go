@@ -0,0 +1,4 @@
+// Package health_v1 contains the generated blaze service implementing the semantics of the gRPC health checking protocol.
+package health_v1
+
+//go:generate protoc -I ../../../proto --go_out=module=code.cestus.io/blaze:../../.. --blaze_out=module=code.cestus.io/blaze:../../.. blaze/health/v1/health.proto
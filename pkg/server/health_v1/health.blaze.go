@@ -0,0 +1,361 @@
+// Code generated by protoc-gen-blaze. DO NOT EDIT.
+// versions:
+// 	blaze-gen-go v0.7.2
+// 	protoc        v4.24.4
+// source: blaze/health/v1/health.proto
+
+package health_v1
+
+import (
+	bytes "bytes"
+	blaze "code.cestus.io/blaze"
+	blazemetrics "code.cestus.io/blaze/pkg/blazemetrics"
+	_ "code.cestus.io/blaze/pkg/blazeoptions"
+	blazetrace "code.cestus.io/blaze/pkg/blazetrace"
+	context "context"
+	fmt "fmt"
+	v5 "github.com/go-chi/chi/v5"
+	logr "github.com/go-logr/logr"
+	grpc_health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+	proto "google.golang.org/protobuf/proto"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	http "net/http"
+	time "time"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ================
+// Health Interface
+// ================
+
+// Health implements the semantics of the gRPC health checking protocol
+// (https://github.com/grpc/grpc/blob/master/doc/health-checking.md) as a blaze service.
+type Health interface {
+	// Check returns the serving status of the requested service. An empty service
+	// name checks the overall health of the server.
+	Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error)
+}
+
+// =============
+// Health Client
+// =============
+
+// HealthClient is implemented by the clients of Health.
+// Its WithOptions methods accept options for a single call.
+type HealthClient interface {
+	Health
+	// CheckWithOptions calls Check with the options of the call.
+	CheckWithOptions(context.Context, *grpc_health_v1.HealthCheckRequest, ...blaze.CallOption) (*grpc_health_v1.HealthCheckResponse, error)
+}
+
+type healthClient struct {
+	client  blaze.HTTPClient
+	urls    [1]string
+	opts    blaze.ClientOptions
+	trace   blazetrace.ClientTracer
+	metrics blazemetrics.ClientMetrics
+	codec   blaze.Codec
+	err     error
+}
+
+// NewHealthClient creates a client that implements the Health interface.
+// It communicates using the codec set WithCodec (protobuf by default) and can be configured with a custom HTTPClient.
+// The client implements HealthClient, whose WithOptions methods accept options for a single call.
+// Calls of a client with invalid options fail with an internal error.
+func NewHealthClient(addr string, client blaze.HTTPClient, opts ...blaze.ClientOption) Health {
+	if c, ok := client.(*http.Client); ok {
+		client = blaze.WithoutRedirects(c)
+	}
+
+	clientOpts := blaze.ClientOptions{
+		Trace:   blazetrace.NewClientTracer(),
+		Metrics: blazemetrics.NewClientMetrics(),
+	}
+	for _, o := range opts {
+		o(&clientOpts)
+	}
+
+	prefix := blaze.UrlBase(addr) + HealthPathPrefix
+	urls := [1]string{
+		prefix + "/" + "Check",
+	}
+
+	c := &healthClient{
+		client:  client,
+		urls:    urls,
+		opts:    clientOpts,
+		trace:   clientOpts.Trace,
+		metrics: clientOpts.Metrics,
+		codec:   clientOpts.ClientCodec(),
+	}
+	// invalid options fail every call
+	if err := clientOpts.Validate(); err != nil {
+		c.err = blaze.ErrorInternalWith(err, "invalid client options: "+err.Error())
+	}
+	return c
+}
+
+// NewHealthProtobufClient creates a Protobuf client that implements the Health interface.
+// It communicates using Protobuf and can be configured with a custom HTTPClient.
+func NewHealthProtobufClient(addr string, client blaze.HTTPClient, opts ...blaze.ClientOption) Health {
+	return NewHealthClient(addr, client, append([]blaze.ClientOption{blaze.WithCodec(blaze.ProtobufCodec{})}, opts...)...)
+}
+
+// NewHealthJSONClient creates a JSON client that implements the Health interface.
+// It communicates using JSON and can be configured with a custom HTTPClient.
+func NewHealthJSONClient(addr string, client blaze.HTTPClient, opts ...blaze.ClientOption) Health {
+	return NewHealthClient(addr, client, append([]blaze.ClientOption{blaze.WithCodec(blaze.NewJSONCodec())}, opts...)...)
+}
+
+func (s *healthClient) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return s.CheckWithOptions(ctx, in)
+}
+
+func (s *healthClient) CheckWithOptions(ctx context.Context, in *grpc_health_v1.HealthCheckRequest, opts ...blaze.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	callOpts := blaze.NewCallOptions(opts...)
+	ctx, cancel := callOpts.Context(ctx)
+	defer cancel()
+	ctx, span := s.trace.StartSpan(ctx, "Check", blazetrace.WithAttributes(blazetrace.ClientName.String("Health")))
+	ctx = s.trace.AnnotateWithClientTrace(ctx)
+	defer s.trace.EndSpan(span)
+	ctx = blaze.WithMethodInfo(ctx, blaze.MethodInfo{Service: "Health", Method: "Check", Idempotency: blaze.IdempotencyUnknown})
+	ctx, call := s.metrics.StartCall(ctx, "Health", "Check", callOpts.CodecOr(s.codec).Name())
+	defer s.metrics.EndCall(ctx, call)
+	out := new(grpc_health_v1.HealthCheckResponse)
+	err := s.doRequest(ctx, s.client, s.urls[0], in, out, callOpts)
+	if err != nil {
+		blerr, ok := err.(blaze.Error)
+		if !ok {
+			blerr = blaze.ErrorInternalWith(err, "")
+		}
+		span.SetStatus(blaze.OtelCodeFromErrorType(blerr), blerr.Error())
+		call.SetError(blerr)
+		return nil, blerr
+	}
+	span.SetStatus(blaze.OtelCodeFromErrorType(nil), "")
+	return out, nil
+}
+
+// doRequest makes a request to the remote Blaze service encoded with the codec of the call or the client.
+func (s *healthClient) doRequest(ctx context.Context, client blaze.HTTPClient, url string, in, out proto.Message, callOpts *blaze.CallOptions) (err error) {
+	codec := callOpts.CodecOr(s.codec)
+	reqBodyBytes, err := codec.Marshal(in)
+	if err != nil {
+		return blaze.ErrorInternalWith(err, "failed to marshal request")
+	}
+	blazemetrics.CallFromContext(ctx).AddRequestSize(int64(len(reqBodyBytes)))
+	if err = ctx.Err(); err != nil {
+		return blaze.ClientErrorFromTransport(err, "aborted because context was done")
+	}
+
+	var req *http.Request
+	if info, _ := blaze.MethodInfoFromContext(ctx); info.Idempotency == blaze.NoSideEffects {
+		req, err = blaze.NewGetHTTPRequest(ctx, url, reqBodyBytes, codec, "v0.7.2")
+	} else {
+		req, err = blaze.NewHTTPRequest(ctx, url, bytes.NewReader(reqBodyBytes), codec.ContentTypes()[0], "v0.7.2")
+	}
+	if err != nil {
+		return blaze.ErrorInternalWith(err, "could not build request")
+	}
+	if err = blaze.AttachCredentials(ctx, req, s.opts); err != nil {
+		return err
+	}
+	callOpts.PrepareRequest(req)
+	if err = blaze.CompressRequest(req, reqBodyBytes, s.opts); err != nil {
+		return blaze.ErrorInternalWith(err, "failed to compress request")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return blaze.ClientErrorFromTransport(err, "failed to do request")
+	}
+
+	defer func() {
+		cerr := resp.Body.Close()
+		if err == nil && cerr != nil {
+			err = blaze.ErrorInternalWith(cerr, "failed to close response body")
+		}
+		callOpts.CaptureResponse(resp)
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return blaze.ClientErrorFromTransport(err, "aborted because context was done")
+	}
+
+	if resp.StatusCode != 200 {
+		return blaze.ErrorFromResponse(resp)
+	}
+
+	respBodyBytes, err := blaze.ReadResponseBody(resp)
+	if err != nil {
+		return blaze.ClientErrorFromTransport(err, "failed to read response body")
+	}
+	blazemetrics.CallFromContext(ctx).AddResponseSize(int64(len(respBodyBytes)))
+	if err = ctx.Err(); err != nil {
+		return blaze.ClientErrorFromTransport(err, "aborted because context was done")
+	}
+
+	if err = codec.Unmarshal(respBodyBytes, out); err != nil {
+		return blaze.ErrorInternalWith(err, "failed to unmarshal response")
+	}
+	return nil
+}
+
+// ==============
+// Health Service
+// ==============
+
+type healthService struct {
+	Health
+	log            logr.Logger
+	mux            *v5.Mux
+	mountPath      string
+	serviceTracer  blazetrace.ServiceTracer
+	serviceMetrics blazemetrics.ServiceMetrics
+	serviceOptions blaze.ServiceOptions
+}
+
+func NewHealthService(svc Health, log logr.Logger, opts ...blaze.ServiceOption) blaze.Service {
+	serviceOptions := blaze.ServiceOptions{
+		Trace:   blazetrace.NewServiceTracer(),
+		Metrics: blazemetrics.NewServiceMetrics(),
+	}
+	for _, o := range opts {
+		o(&serviceOptions)
+	}
+	var r *v5.Mux
+	if serviceOptions.Mux != nil {
+		r = serviceOptions.Mux
+	} else {
+		r = v5.NewRouter()
+	}
+
+	service := healthService{
+		log:            log,
+		mux:            r,
+		serviceOptions: serviceOptions,
+		mountPath:      HealthPathPrefix,
+		serviceTracer:  serviceOptions.Trace,
+		serviceMetrics: serviceOptions.Metrics,
+		Health:         svc,
+	}
+	r.Use(service.serviceTracer.TracingMiddleware("Health"))
+	r.Use(service.serviceMetrics.Middleware("Health"))
+	r.Post("/Check", service.serveCheck)
+	return &service
+}
+
+const HealthPathPrefix = "/health/v1"
+
+// HealthAuthorization lists the scopes and roles required by the methods of Health.
+var HealthAuthorization = blaze.AuthorizationTable{
+	HealthPathPrefix + "/Check": {Public: true},
+}
+
+// Methods.
+func (s *healthService) serveCheck(resp http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	ctx = s.serviceTracer.InjectTracer(ctx)
+	ctx, span := s.serviceTracer.StartSpan(ctx, "Health/Check", blazetrace.WithAttributes(blazetrace.RPCSystemBlaze, blazetrace.RPCServiceKey.String("Health"), blazetrace.RPCMethodKey.String("Check")))
+	defer s.serviceTracer.EndSpan(span)
+	ctx = blaze.WithRequestLogger(ctx, s.log, "Health", "Check")
+	ctx = blaze.WithServerMetadata(ctx, req)
+	codec, codecErr := s.serviceOptions.RequestCodec(req)
+	if codecErr == nil {
+		blazemetrics.CallFromContext(ctx).SetContentType(codec.Name())
+	}
+	respCodec := s.serviceOptions.ResponseCodec(req.Header.Get("Accept"), codec)
+	ctx = blaze.WithResponseCodec(ctx, respCodec)
+	ctx, cancel, err := blaze.ServerContextWithTimeout(ctx, req, s.serviceOptions.MaxTimeout)
+	defer cancel()
+	if err != nil {
+		blaze.ServerWriteError(ctx, resp, err, s.log)
+		return
+	}
+	if codecErr != nil {
+		blaze.ServerWriteError(ctx, resp, codecErr, s.log)
+		return
+	}
+
+	decodeStart := time.Now()
+	buf, err := blaze.ReadRequest(resp, req, codec, s.serviceOptions.MaxRequestBytes)
+	if err != nil {
+		blaze.ServerWriteError(ctx, resp, err, s.log)
+		return
+	}
+	blazemetrics.CallFromContext(ctx).AddRequestSize(int64(len(buf)))
+	reqContent := new(grpc_health_v1.HealthCheckRequest)
+	if err = codec.Unmarshal(buf, reqContent); err != nil {
+		blaze.ServerWriteError(ctx, resp, blaze.ServerDecodeError(codec, err, buf), s.log)
+		return
+	}
+	blazetrace.MessageReceived(ctx, len(buf), time.Since(decodeStart))
+
+	// Call service method
+	var respContent *grpc_health_v1.HealthCheckResponse
+	func() {
+		defer blaze.ServerEnsurePanicResponses(ctx, resp, s.log)
+		respContent, err = s.Health.Check(ctx, reqContent)
+	}()
+	// the client no longer waits for the response once the deadline expired
+	err = blaze.ServerDeadlineError(ctx, err)
+
+	if err != nil {
+		blaze.ServerWriteError(ctx, resp, err, s.log)
+		return
+	}
+	if respContent == nil {
+		blaze.ServerWriteError(ctx, resp, blaze.ErrorInternal("received a nil *grpc_health_v1.HealthCheckResponse and nil error while calling Check. nil responses are not supported"), s.log)
+		return
+	}
+
+	encodeStart := time.Now()
+	respBytes, err := respCodec.Marshal(respContent)
+	if err != nil {
+		blaze.ServerWriteError(ctx, resp, blaze.ErrorInternalWith(err, "failed to marshal "+respCodec.Name()+" response"), s.log)
+		return
+	}
+	blazemetrics.CallFromContext(ctx).AddResponseSize(int64(len(respBytes)))
+	blazetrace.MessageSent(ctx, len(respBytes), time.Since(encodeStart))
+
+	respBody, encoding, err := blaze.CompressResponseBody(req, respBytes, s.serviceOptions)
+	if err != nil {
+		blaze.ServerWriteError(ctx, resp, blaze.ErrorInternalWith(err, "failed to compress response"), s.log)
+		return
+	}
+	if encoding != "" {
+		resp.Header().Set("Content-Encoding", encoding)
+	}
+	blaze.WriteResponseMetadata(ctx, resp)
+	if blaze.ServerNotModified(resp, req) {
+		return
+	}
+	resp.Header().Add("Vary", "Accept")
+	resp.Header().Add("Vary", "Accept-Encoding")
+	resp.Header().Set("Content-Type", respCodec.ContentTypes()[0])
+	blaze.SetContentLength(resp, len(respBody))
+	resp.WriteHeader(http.StatusOK)
+
+	if n, err := resp.Write(respBody); err != nil {
+		msg := fmt.Sprintf("failed to write response, %d of %d bytes written: %s", n, len(respBody), err.Error())
+		blerr := blaze.ErrorInternal(msg)
+		blaze.LoggerFromContext(ctx).Error(blerr, msg)
+	}
+}
+
+func (s *healthService) Mux() *v5.Mux {
+	return s.mux
+}
+
+func (s *healthService) MountPath() string {
+	return s.mountPath
+}
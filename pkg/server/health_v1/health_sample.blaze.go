@@ -0,0 +1,28 @@
+// Code generated by protoc-gen-blaze. DO NOT EDIT.
+// versions:
+// 	blaze-gen-go v0.7.2
+// 	protoc        v4.24.4
+// source: blaze/health/v1/health.proto
+
+package health_v1
+
+import (
+	blaze "code.cestus.io/blaze"
+	_ "code.cestus.io/blaze/pkg/blazeoptions"
+	context "context"
+	grpc_health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ============================
+// Health sample implementation
+// ============================
+
+type healthServiceSample struct {
+}
+
+// Methods.
+// Check returns the serving status of the requested service. An empty service
+// name checks the overall health of the server.
+func (api *healthServiceSample) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, blaze.ErrorUnimplemented("")
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type healthService struct {
+	health *Health
+}
+
+// Check implements the gRPC health checking protocol semantics.
+// The empty service name reports the readiness of the server, any other name the check registered under that name
+func (s *healthService) Check(ctx context.Context, in *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if in.GetService() == "" {
+		if s.health.Readiness(ctx).Healthy() {
+			status = grpc_health_v1.HealthCheckResponse_SERVING
+		}
+		return &grpc_health_v1.HealthCheckResponse{Status: status}, nil
+	}
+	res, ok := s.health.Check(ctx, in.GetService())
+	if !ok {
+		return nil, blaze.ErrorNotFound("unknown service").WithMeta("service", in.GetService())
+	}
+	if res.Status == HealthStatusOK && !s.health.ShuttingDown() {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: status}, nil
+}
+
+// NewHealthService creates a blaze service reporting the health of the registered checks
+// following the semantics of the gRPC health checking protocol
+func NewHealthService(health *Health, log logr.Logger, opts ...blaze.ServiceOption) blaze.Service {
+	return health_v1.NewHealthService(&healthService{health: health}, log, opts...)
+}
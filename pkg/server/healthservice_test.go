@@ -0,0 +1,74 @@
+package server_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+var _ = Describe("HealthService", func() {
+	var (
+		health *server.Health
+		client health_v1.Health
+		srv    *httptest.Server
+	)
+
+	serve := func(h *server.Health) {
+		svc := server.NewHealthService(h, logr.Discard())
+		mux := chi.NewMux()
+		mux.Mount(svc.MountPath(), svc.Mux())
+		srv = httptest.NewServer(mux)
+		client = health_v1.NewHealthJSONClient(srv.URL, srv.Client())
+	}
+
+	BeforeEach(func() {
+		health = server.NewHealth()
+		health.AddLivenessCheck("live", pass)
+		health.AddReadinessCheck("ready", fail)
+		serve(health)
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	check := func(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	It("reports the readiness of the server for the empty name", func() {
+		Expect(check("")).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+		srv.Close()
+		serve(server.NewHealth())
+		Expect(check("")).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+	})
+
+	It("reports the check with the name", func() {
+		Expect(check("live")).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+		Expect(check("ready")).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+	})
+
+	It("reports nothing serving once the server is shutting down", func() {
+		health.SetShuttingDown()
+		Expect(check("live")).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+	})
+
+	It("does not find unknown names", func() {
+		_, err := check("unknown")
+		Expect(err).To(HaveOccurred())
+		blerr, ok := err.(blaze.Error)
+		Expect(ok).To(BeTrue())
+		Expect(blerr.Type()).To(Equal(blaze.ErrorNotFound("").Type()))
+		Expect(blerr.Meta("service")).To(Equal("unknown"))
+	})
+})
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"code.cestus.io/blaze"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID is a middleware which accepts the X-Request-Id of the request or generates a new one.
+// The ID is stored in the context and echoed in the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(blaze.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		resp.Header().Set(blaze.RequestIDHeader, id)
+		next.ServeHTTP(resp, req.WithContext(blaze.WithRequestID(req.Context(), id)))
+	})
+}
+
+// validRequestID only accepts printable ascii IDs of limited length so they can be logged safely
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,68 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/server"
+)
+
+var _ = Describe("RequestID", func() {
+	var contextID string
+	serve := func(id string) *httptest.ResponseRecorder {
+		contextID = ""
+		handler := server.RequestID(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			contextID = blaze.RequestIDFromContext(req.Context())
+		}))
+		req := httptest.NewRequest("POST", "/svc/Method", nil)
+		if id != "" {
+			req.Header.Set(blaze.RequestIDHeader, id)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	DescribeTable("accepts valid incoming IDs",
+		func(id string) {
+			rec := serve(id)
+			Expect(rec.Header().Get(blaze.RequestIDHeader)).To(Equal(id))
+			Expect(contextID).To(Equal(id))
+		},
+		Entry("uuid", "0f8fad5b-d9cb-469f-a165-70867728950e"),
+		Entry("printable ascii", "!~abc.DEF_123"),
+		Entry("maximum length", strings.Repeat("a", 128)),
+	)
+
+	DescribeTable("replaces invalid incoming IDs",
+		func(id string) {
+			rec := serve(id)
+			generated := rec.Header().Get(blaze.RequestIDHeader)
+			Expect(generated).ToNot(Equal(id))
+			Expect(generated).To(MatchRegexp(`^[0-9a-f]{32}$`))
+			Expect(contextID).To(Equal(generated))
+		},
+		Entry("too long", strings.Repeat("a", 129)),
+		Entry("space", "abc def"),
+		Entry("tab", "abc\tdef"),
+		Entry("control character", "abc\x01"),
+		Entry("non ascii", "abcä"),
+		Entry("delete", "abc\x7f"),
+	)
+
+	It("generates an ID if the request has none", func() {
+		rec := serve("")
+		generated := rec.Header().Get(blaze.RequestIDHeader)
+		Expect(generated).To(MatchRegexp(`^[0-9a-f]{32}$`))
+		Expect(contextID).To(Equal(generated))
+	})
+
+	It("generates unique IDs", func() {
+		Expect(serve("").Header().Get(blaze.RequestIDHeader)).ToNot(Equal(serve("").Header().Get(blaze.RequestIDHeader)))
+	})
+})
@@ -0,0 +1,22 @@
+package blaze
+
+import "context"
+
+// RequestIDHeader is the header carrying the request ID
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDMetaKey is the meta key of error responses carrying the request ID
+const RequestIDMetaKey = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID adds the request ID to the context. Clients forward it on outgoing calls
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of the context or an empty string if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,227 @@
+package blaze
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultResolverInterval = 30 * time.Second
+
+// Endpoint is a single replica of a service
+type Endpoint struct {
+	// Address is the host:port of the replica
+	Address string
+}
+
+// Resolver provides the endpoints of a service to a balancing client
+type Resolver interface {
+	// Endpoints returns the currently known endpoints
+	Endpoints() []Endpoint
+	// Close stops the resolver
+	Close() error
+}
+
+type staticResolver struct {
+	endpoints []Endpoint
+}
+
+// NewStaticResolver creates a resolver for a fixed list of host:port addresses
+func NewStaticResolver(addrs ...string) Resolver {
+	r := &staticResolver{}
+	for _, a := range addrs {
+		r.endpoints = append(r.endpoints, Endpoint{Address: a})
+	}
+	return r
+}
+
+func (r *staticResolver) Endpoints() []Endpoint { return append([]Endpoint{}, r.endpoints...) }
+
+func (r *staticResolver) Close() error { return nil }
+
+// ResolverOption is a functional option for extending polling resolvers
+type ResolverOption func(*pollingResolver)
+
+// WithResolveInterval sets the interval the endpoints are looked up again
+func WithResolveInterval(interval time.Duration) ResolverOption {
+	return func(r *pollingResolver) {
+		r.interval = interval
+	}
+}
+
+// WithNetResolver sets the resolver used for DNS lookups. The default is net.DefaultResolver
+func WithNetResolver(resolver *net.Resolver) ResolverOption {
+	return func(r *pollingResolver) {
+		r.net = resolver
+	}
+}
+
+// WithSRV makes a DNS resolver look up SRV records of the given service and protocol instead of A records
+func WithSRV(service string, proto string) ResolverOption {
+	return func(r *pollingResolver) {
+		r.srvService = service
+		r.srvProto = proto
+	}
+}
+
+// pollingResolver looks up endpoints periodically and keeps the last successful result
+type pollingResolver struct {
+	lookup     func(ctx context.Context) ([]Endpoint, error)
+	interval   time.Duration
+	net        *net.Resolver
+	srvService string
+	srvProto   string
+
+	mu        sync.RWMutex
+	endpoints []Endpoint
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newPollingResolver(opts ...ResolverOption) *pollingResolver {
+	r := &pollingResolver{
+		interval: defaultResolverInterval,
+		net:      net.DefaultResolver,
+		done:     make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// start resolves the endpoints once and keeps polling in the background
+func (r *pollingResolver) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	if err := r.resolve(ctx); err != nil {
+		cancel()
+		close(r.done)
+		return err
+	}
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = r.resolve(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *pollingResolver) resolve(ctx context.Context) error {
+	endpoints, err := r.lookup(ctx)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return errors.New("no endpoints resolved")
+	}
+	r.mu.Lock()
+	r.endpoints = endpoints
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *pollingResolver) Endpoints() []Endpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Endpoint{}, r.endpoints...)
+}
+
+func (r *pollingResolver) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// NewDNSResolver creates a resolver polling DNS. Without WithSRV the A/AAAA records of the host of
+// target (host:port) are used with the port of target, with WithSRV target is the domain of the SRV records
+func NewDNSResolver(target string, opts ...ResolverOption) (Resolver, error) {
+	r := newPollingResolver(opts...)
+	if r.srvService != "" {
+		r.lookup = func(ctx context.Context) ([]Endpoint, error) {
+			_, records, err := r.net.LookupSRV(ctx, r.srvService, r.srvProto, target)
+			if err != nil {
+				return nil, err
+			}
+			endpoints := make([]Endpoint, 0, len(records))
+			for _, srv := range records {
+				host := strings.TrimSuffix(srv.Target, ".")
+				endpoints = append(endpoints, Endpoint{Address: net.JoinHostPort(host, strconv.Itoa(int(srv.Port)))})
+			}
+			return endpoints, nil
+		}
+	} else {
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, err
+		}
+		r.lookup = func(ctx context.Context) ([]Endpoint, error) {
+			addrs, err := r.net.LookupHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			endpoints := make([]Endpoint, 0, len(addrs))
+			for _, a := range addrs {
+				endpoints = append(endpoints, Endpoint{Address: net.JoinHostPort(a, port)})
+			}
+			return endpoints, nil
+		}
+	}
+	if err := r.start(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewFileResolver creates a resolver reading host:port addresses from a file, one per line.
+// Empty lines and lines starting with # are ignored. The file is read again when its content changes,
+// a file without addresses keeps the last endpoints until addresses are written to it
+func NewFileResolver(path string, opts ...ResolverOption) (Resolver, error) {
+	r := newPollingResolver(opts...)
+	var content []byte
+	r.lookup = func(ctx context.Context) ([]Endpoint, error) {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if content != nil && bytes.Equal(buf, content) {
+			return r.Endpoints(), nil
+		}
+		var endpoints []Endpoint
+		scanner := bufio.NewScanner(bytes.NewReader(buf))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			endpoints = append(endpoints, Endpoint{Address: line})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		if len(endpoints) > 0 {
+			// only a complete list is remembered, so a file emptied while it is rewritten is read again
+			content = buf
+		}
+		return endpoints, nil
+	}
+	if err := r.start(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,98 @@
+package blaze_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+func addresses(r blaze.Resolver) []string {
+	var addrs []string
+	for _, e := range r.Endpoints() {
+		addrs = append(addrs, e.Address)
+	}
+	return addrs
+}
+
+var _ = Describe("Resolver", func() {
+	It("resolves static addresses", func() {
+		r := blaze.NewStaticResolver("a:1", "b:2")
+		Expect(addresses(r)).To(Equal([]string{"a:1", "b:2"}))
+		r.Endpoints()[0].Address = "changed"
+		Expect(addresses(r)).To(Equal([]string{"a:1", "b:2"}))
+		Expect(r.Close()).To(Succeed())
+	})
+
+	Context("DNS", func() {
+		It("resolves the host with the port of the target", func() {
+			r, err := blaze.NewDNSResolver("127.0.0.1:8080", blaze.WithResolveInterval(time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			defer r.Close()
+			Expect(addresses(r)).To(Equal([]string{"127.0.0.1:8080"}))
+		})
+		It("rejects targets without port", func() {
+			_, err := blaze.NewDNSResolver("127.0.0.1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("file", func() {
+		var path string
+		write := func(content string) {
+			info, statErr := os.Stat(path)
+			ExpectWithOffset(1, os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+			if statErr == nil {
+				// keep the modification time to rewrite the file within the same tick of the clock
+				ExpectWithOffset(1, os.Chtimes(path, info.ModTime(), info.ModTime())).To(Succeed())
+			}
+		}
+		newResolver := func() blaze.Resolver {
+			r, err := blaze.NewFileResolver(path, blaze.WithResolveInterval(5*time.Millisecond))
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			DeferCleanup(r.Close)
+			return r
+		}
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "endpoints")
+		})
+
+		It("reads an address per line ignoring comments", func() {
+			write("# replicas\na:1\n\n  b:2  \n")
+			Expect(addresses(newResolver())).To(Equal([]string{"a:1", "b:2"}))
+		})
+		It("fails without file or without addresses", func() {
+			_, err := blaze.NewFileResolver(path)
+			Expect(err).To(HaveOccurred())
+			write("# none yet\n")
+			_, err = blaze.NewFileResolver(path)
+			Expect(err).To(HaveOccurred())
+		})
+		It("reads a file rewritten within the same tick", func() {
+			write("a:1\n")
+			r := newResolver()
+			write("b:2\n")
+			Eventually(func() []string { return addresses(r) }).Should(Equal([]string{"b:2"}))
+		})
+		It("keeps the endpoints while the file is empty and reads it once filled", func() {
+			write("a:1\n")
+			r := newResolver()
+			write("")
+			Consistently(func() []string { return addresses(r) }, 50*time.Millisecond).Should(Equal([]string{"a:1"}))
+			write("b:2\n")
+			Eventually(func() []string { return addresses(r) }).Should(Equal([]string{"b:2"}))
+		})
+		It("reads a file restored to a former content", func() {
+			write("a:1\n")
+			r := newResolver()
+			write("")
+			time.Sleep(20 * time.Millisecond)
+			write("a:1\nc:3\n")
+			Eventually(func() []string { return addresses(r) }).Should(Equal([]string{"a:1", "c:3"}))
+		})
+	})
+})
@@ -0,0 +1,232 @@
+package blaze
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cestus.io/blaze/pkg/blazetrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryPolicy configures if and when a failed call is sent again
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one. Values below 2 disable retries
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between attempts
+	MaxBackoff time.Duration
+	// BackoffMultiplier grows the wait time after each attempt
+	BackoffMultiplier float64
+	// Jitter is the fraction (0-1) of the wait time which is randomly subtracted to spread retries of many clients
+	Jitter float64
+	// RetryableErrorTypes lists the Error.Type() of errors which are retried e.g. ErrorUnavailable("").Type()
+	RetryableErrorTypes []string
+}
+
+// DefaultRetryPolicy retries unavailable and resource exhausted errors up to 3 attempts.
+// Requests rejected as too large are never retried
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+		BackoffMultiplier: 2,
+		Jitter:            0.2,
+		RetryableErrorTypes: []string{
+			ErrorUnavailable("").Type(),
+			ErrorResourceExhausted("").Type(),
+		},
+	}
+}
+
+func (p RetryPolicy) retryable(blerr Error) bool {
+	if tooLarge(blerr) {
+		return false
+	}
+	for _, t := range p.RetryableErrorTypes {
+		if blerr.Type() == t {
+			return true
+		}
+	}
+	return false
+}
+
+// tooLarge reports whether the request was rejected for its size by the service or an intermediary,
+// which fails again on every attempt
+func tooLarge(blerr Error) bool {
+	return blerr.Meta(MaxRequestBytesMetaKey) != "" || blerr.Meta(HTTPStatusMetaKey) == strconv.Itoa(http.StatusRequestEntityTooLarge)
+}
+
+// backoff returns the wait time after the given attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// RetryOption is a functional option for extending a retrying client
+type RetryOption func(*retryingClient)
+
+// WithDefaultRetryPolicy sets the policy of methods without a policy of their own
+func WithDefaultRetryPolicy(policy RetryPolicy) RetryOption {
+	return func(c *retryingClient) {
+		c.policy = policy
+	}
+}
+
+// WithMethodRetryPolicy sets the policy of a single method given in the form Service/Method
+func WithMethodRetryPolicy(method string, policy RetryPolicy) RetryOption {
+	return func(c *retryingClient) {
+		c.methodPolicies[method] = policy
+	}
+}
+
+type retryingClient struct {
+	client         HTTPClient
+	policy         RetryPolicy
+	methodPolicies map[string]RetryPolicy
+}
+
+// NewRetryingClient wraps client to retry failed calls of generated clients.
+// Only calls of methods marked as idempotent by their idempotency_level option or with a policy
+// set by the WithRetryPolicy call option are retried.
+// Request bodies are buffered so they can be replayed and a Retry-After header of the response is honoured
+func NewRetryingClient(client HTTPClient, opts ...RetryOption) HTTPClient {
+	c := &retryingClient{
+		client:         client,
+		policy:         DefaultRetryPolicy(),
+		methodPolicies: map[string]RetryPolicy{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// retryPolicyKey is the context key of the retry policy of a call set WithRetryPolicy
+type retryPolicyKey struct{}
+
+func (c *retryingClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	info, ok := MethodInfoFromContext(ctx)
+	policy, callPolicy := ctx.Value(retryPolicyKey{}).(RetryPolicy)
+	if !callPolicy && (!ok || !info.Idempotency.IsIdempotent()) {
+		return c.client.Do(req)
+	}
+	if !callPolicy {
+		if policy, ok = c.methodPolicies[info.FullName()]; !ok {
+			policy = c.policy
+		}
+	}
+	if policy.MaxAttempts < 2 {
+		return c.client.Do(req)
+	}
+	getBody, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		r := req.Clone(ctx)
+		if r.Body, err = getBody(); err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			r.Header.Set(TimeoutHeader, encodeTimeout(time.Until(deadline)))
+		}
+		resp, err := c.client.Do(r)
+		var blerr Error
+		blerr, resp = classifyAttempt(resp, err)
+		if blerr == nil || attempt >= policy.MaxAttempts || !policy.retryable(blerr) {
+			return resp, err
+		}
+		delay := policy.backoff(attempt)
+		if after, ok := retryAfter(resp); ok && after > delay {
+			delay = after
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		trace.SpanFromContext(ctx).AddEvent("blaze.retry", trace.WithAttributes(
+			attribute.Int("blaze.retry.attempt", attempt+1),
+			attribute.String("blaze.retry.delay", delay.String()),
+			blazetrace.ErrorTypeKey.String(blerr.Type()),
+		))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// replayableBody returns a function producing a fresh copy of the request body for every attempt
+func replayableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, ErrorInternalWith(err, "failed to buffer request body")
+	}
+	_ = req.Body.Close()
+	return func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(buf)), nil }, nil
+}
+
+// classifyAttempt returns the blaze error of a failed attempt. The body of error responses is buffered
+// so the response can still be returned to the caller after it was inspected
+func classifyAttempt(resp *http.Response, err error) (Error, *http.Response) {
+	if err != nil {
+		return ClientErrorFromTransport(err, "failed to do request"), resp
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil, resp
+	}
+	buf, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	if readErr != nil {
+		return ClientErrorFromTransport(readErr, "failed to read server error response body"), resp
+	}
+	inspected := *resp
+	inspected.Body = io.NopCloser(bytes.NewReader(buf))
+	return ErrorFromResponse(&inspected), resp
+}
+
+// retryAfter parses the Retry-After header given in seconds or as http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
@@ -0,0 +1,205 @@
+package blaze_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cestus.io/blaze"
+)
+
+// blazeError answers with the blaze error encoded like a generated service does
+func blazeError(err error) attempt {
+	return func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		blaze.ServerWriteError(context.Background(), rec, err, logr.Discard())
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	}
+}
+
+var _ = Describe("RetryingClient", func() {
+	var inner *scriptedClient
+	policy := blaze.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	BeforeEach(func() {
+		inner = &scriptedClient{}
+	})
+
+	DescribeTable("does not retry requests rejected as too large",
+		func(rejection attempt) {
+			inner.attempts = []attempt{rejection, respond(200, "retried")}
+			client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+			resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(inner.calls()).To(Equal(1))
+		},
+		Entry("by the service", blazeError(blaze.ErrorRequestTooLarge(100))),
+		Entry("by an intermediary", respond(413, "<html>too large</html>")),
+	)
+
+	It("retries other resource exhausted errors", func() {
+		inner.attempts = []attempt{blazeError(blaze.ErrorResourceExhausted("busy")), respond(200, "retried")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("retried"))
+	})
+
+	It("retries until a call succeeds", func() {
+		inner.attempts = []attempt{respond(503, ""), fail(io.ErrUnexpectedEOF), respond(200, "third")}
+		policy := policy
+		policy.RetryableErrorTypes = append(policy.RetryableErrorTypes, blaze.ErrorInternal("").Type())
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readBody(resp)).To(Equal("third"))
+	})
+
+	It("returns the last failure after the maximum attempts", func() {
+		inner.attempts = []attempt{respond(503, "1"), respond(503, "2"), respond(503, "3"), respond(200, "4")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(readBody(resp)).To(Equal("3"))
+		Expect(inner.calls()).To(Equal(3))
+	})
+
+	It("does not retry errors of other types", func() {
+		inner.attempts = []attempt{blazeError(blaze.ErrorInvalidArgument("name", "is empty")), respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("only retries idempotent methods", func() {
+		inner.attempts = []attempt{respond(503, ""), respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.IdempotencyUnknown))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("retries any method with the policy of the call", func() {
+		inner.attempts = []attempt{respond(503, ""), respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(blaze.RetryPolicy{}))
+		ctx, cancel := blaze.NewCallOptions(blaze.WithRetryPolicy(policy)).Context(context.Background())
+		defer cancel()
+		resp, err := client.Do(idempotentRequest(ctx, blaze.IdempotencyUnknown))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+	})
+
+	It("uses the policy of the method", func() {
+		inner.attempts = []attempt{respond(503, ""), respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy), blaze.WithMethodRetryPolicy("Svc/Method", blaze.RetryPolicy{MaxAttempts: 1}))
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("replays the body and sends the remaining time with every attempt", func() {
+		var bodies, timeouts []string
+		record := func(status int) attempt {
+			return func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+				timeouts = append(timeouts, req.Header.Get(blaze.TimeoutHeader))
+				return respond(status, "")(req)
+			}
+		}
+		inner.attempts = []attempt{record(503), record(503), record(200)}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_, err := client.Do(idempotentRequest(ctx, blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bodies).To(Equal([]string{"payload", "payload", "payload"}))
+		for _, timeout := range timeouts {
+			Expect(timeout).ToNot(BeEmpty())
+		}
+	})
+
+	It("waits at least as long as Retry-After", func() {
+		tooBusy := func(req *http.Request) (*http.Response, error) {
+			resp, _ := respond(503, "")(req)
+			resp.Header.Set("Retry-After", "1")
+			return resp, nil
+		}
+		inner.attempts = []attempt{tooBusy, respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		start := time.Now()
+		resp, err := client.Do(idempotentRequest(context.Background(), blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+	})
+
+	It("gives up if the deadline is reached before the next attempt", func() {
+		tooBusy := func(req *http.Request) (*http.Response, error) {
+			resp, _ := respond(503, "")(req)
+			resp.Header.Set("Retry-After", "60")
+			return resp, nil
+		}
+		inner.attempts = []attempt{tooBusy, respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		start := time.Now()
+		resp, err := client.Do(idempotentRequest(ctx, blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		Expect(inner.calls()).To(Equal(1))
+	})
+
+	It("stops waiting when the caller cancels", func() {
+		policy := policy
+		policy.InitialBackoff = time.Minute
+		inner.attempts = []attempt{respond(503, ""), respond(200, "")}
+		client := blaze.NewRetryingClient(inner, blaze.WithDefaultRetryPolicy(policy))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		resp, err := client.Do(idempotentRequest(ctx, blaze.Idempotent))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(503))
+		Expect(inner.calls()).To(Equal(1))
+	})
+})
+
+var _ = DescribeTable("RetryPolicy backoff",
+	func(policy blaze.RetryPolicy, expected ...time.Duration) {
+		for i, e := range expected {
+			Expect(policy.Backoff(i+1)).To(Equal(e), "attempt %d", i+1)
+		}
+	},
+	Entry("grows by the multiplier up to the maximum",
+		blaze.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 500 * time.Millisecond, BackoffMultiplier: 2},
+		100*time.Millisecond, 200*time.Millisecond, 400*time.Millisecond, 500*time.Millisecond, 500*time.Millisecond),
+	Entry("stays constant with a multiplier below 1",
+		blaze.RetryPolicy{InitialBackoff: 100 * time.Millisecond, BackoffMultiplier: 0.5},
+		100*time.Millisecond, 100*time.Millisecond, 100*time.Millisecond),
+	Entry("is unbounded without maximum",
+		blaze.RetryPolicy{InitialBackoff: time.Second, BackoffMultiplier: 10},
+		time.Second, 10*time.Second, 100*time.Second),
+)
+
+var _ = It("subtracts at most the jitter from the backoff", func() {
+	policy := blaze.RetryPolicy{InitialBackoff: 100 * time.Millisecond, BackoffMultiplier: 1, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		Expect(policy.Backoff(1)).To(BeNumerically("~", 90*time.Millisecond, 10*time.Millisecond))
+	}
+})
@@ -3,6 +3,8 @@ package blaze
 import (
 	"errors"
 	"strconv"
+
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // ErrorJSON is JSON serialization for blaze errors
@@ -102,3 +104,36 @@ func (r errorRegistry) Contruct(name string, msg string) (Error, error) {
 	}
 	return nil, errors.New("not registered")
 }
+
+// errorJSONToStruct converts an ErrorJSON into a struct message so it can be encoded by any codec
+func errorJSONToStruct(j ErrorJSON) *structpb.Struct {
+	fields := map[string]*structpb.Value{
+		"code":       structpb.NewStringValue(j.Code),
+		"msg":        structpb.NewStringValue(j.Msg),
+		"blaze_type": structpb.NewStringValue(j.Type),
+	}
+	if len(j.Meta) > 0 {
+		meta := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		for k, v := range j.Meta {
+			meta.Fields[k] = structpb.NewStringValue(v)
+		}
+		fields["meta"] = structpb.NewStructValue(meta)
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+// errorJSONFromStruct is the inverse of errorJSONToStruct
+func errorJSONFromStruct(s *structpb.Struct) ErrorJSON {
+	j := ErrorJSON{
+		Code: s.GetFields()["code"].GetStringValue(),
+		Msg:  s.GetFields()["msg"].GetStringValue(),
+		Type: s.GetFields()["blaze_type"].GetStringValue(),
+	}
+	if meta := s.GetFields()["meta"].GetStructValue(); meta != nil {
+		j.Meta = make(map[string]string, len(meta.GetFields()))
+		for k, v := range meta.GetFields() {
+			j.Meta[k] = v.GetStringValue()
+		}
+	}
+	return j
+}
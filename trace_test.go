@@ -0,0 +1,97 @@
+package blaze_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"code.cestus.io/blaze"
+	"code.cestus.io/blaze/pkg/blazetrace"
+	"code.cestus.io/blaze/pkg/server/health_v1"
+)
+
+var _ = Describe("Server spans", func() {
+	var exporter *tracetest.InMemoryExporter
+
+	BeforeEach(func() {
+		exporter = tracetest.NewInMemoryExporter()
+	})
+
+	// serve calls Check of a traced health service and returns the span of the middleware and of the method
+	serve := func(h health_v1.Health) (tracetest.SpanStub, tracetest.SpanStub) {
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		tracer := blazetrace.NewServiceTracer(
+			blazetrace.WithTracer(provider.Tracer("test")),
+			blazetrace.WithTracingMiddleware(func(service string) func(http.Handler) http.Handler {
+				return blazetrace.Middleware(service, blazetrace.WithTracerProvider(provider))
+			}),
+		)
+		svc := health_v1.NewHealthService(h, logr.Discard(), blaze.WithServiceTracer(tracer))
+		mux := chi.NewMux()
+		mux.Mount(svc.MountPath(), svc.Mux())
+		req := httptest.NewRequest(http.MethodPost, health_v1.HealthPathPrefix+"/Check", strings.NewReader(`{"service":"svc"}`))
+		req.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(2))
+		// the method span ends first
+		method, middleware := spans[0], spans[1]
+		Expect(method.Parent.SpanID()).To(Equal(middleware.SpanContext.SpanID()))
+		return middleware, method
+	}
+
+	attributeOf := func(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+		for _, kv := range attrs {
+			if kv.Key == key {
+				return kv.Value
+			}
+		}
+		return attribute.Value{}
+	}
+
+	It("are started per method with the rpc attributes and message sizes", func() {
+		middleware, method := serve(servingHealth{})
+		Expect(middleware.Name).To(Equal("/Check"))
+		Expect(method.Name).To(Equal("Health/Check"))
+		Expect(method.SpanKind).To(Equal(trace.SpanKindServer))
+		Expect(method.Status.Code).To(Equal(codes.Unset))
+		Expect(method.Attributes).To(ContainElements(
+			blazetrace.RPCSystemBlaze,
+			blazetrace.RPCServiceKey.String("Health"),
+			blazetrace.RPCMethodKey.String("Check"),
+			blazetrace.RequestSizeKey.Int(len(`{"service":"svc"}`)),
+		))
+		Expect(attributeOf(method.Attributes, blazetrace.ResponseSizeKey).AsInt64()).To(BeNumerically(">", 0))
+		Expect(method.Events).To(HaveLen(2))
+		Expect(method.Events[0].Attributes).To(ContainElement(semconv.MessageTypeReceived))
+		Expect(method.Events[1].Attributes).To(ContainElement(semconv.MessageTypeSent))
+		Expect(attributeOf(method.Attributes, blazetrace.ErrorTypeKey).Type()).To(Equal(attribute.INVALID))
+	})
+
+	DescribeTable("record the blaze error of the method",
+		func(h health_v1.Health, errorType string, status int, code codes.Code) {
+			middleware, method := serve(h)
+			Expect(attributeOf(method.Attributes, blazetrace.ErrorTypeKey).AsString()).To(Equal(errorType))
+			Expect(method.Status.Code).To(Equal(code))
+			Expect(attributeOf(middleware.Attributes, blazetrace.ErrorTypeKey).AsString()).To(Equal(errorType))
+			Expect(middleware.Attributes).To(ContainElement(semconv.HTTPResponseStatusCode(status)))
+			Expect(middleware.Status.Code).To(Equal(code))
+		},
+		Entry("client error", unknownHealth{}, blaze.ErrorNotFound("").Type(), http.StatusNotFound, codes.Unset),
+		Entry("server error", slowHealth{err: errors.New("failed")}, blaze.ErrorInternal("").Type(), http.StatusInternalServerError, codes.Error),
+		Entry("unavailable", slowHealth{err: blaze.ErrorUnavailable("overloaded")}, blaze.ErrorUnavailable("").Type(), http.StatusServiceUnavailable, codes.Error),
+	)
+})